@@ -1,46 +1,140 @@
 package algorithms
 
 import (
+	"context"
 	"emhun/models"
 	"emhun/utility"
 	"fmt"
+	"log/slog"
 	"sort"
 )
 
 type EMHUN struct {
+	// Transactions are left unchanged by a run, which mines copies.
 	Transactions     []*models.Transaction
 	MinUtility       float64
+	MinUtilityRatio  float64
+	Engine           Engine
 	Rho, Delta, Eta  map[int]bool
 	SortedSecondary  []int
 	SortedEta        []int
 	PrimaryItems     []int
 	UtilityArray     *models.UtilityArray
 	SearchAlgorithms *SearchAlgorithms
+
+	// Workers > 1 explores the primary items concurrently. SplitDepth > 1
+	// also queues the children of nodes with fewer items as separate tasks.
+	Workers    int
+	SplitDepth int
+
+	// Limits caps the run; Status tells whether the last run completed.
+	Limits Limits
+	Status RunStatus
+
+	// Exact marks utilities that are integers scaled by dataset exact mode.
+	// RunContext then refuses transactions whose sums float64 could not all
+	// compute exactly, see utility.CheckExact.
+	Exact bool
+
+	// Constraints restrict the reported itemsets, see constraints.go.
+	Constraints Constraints
+	// MinUtilities gives some dataset items their own minimum utility, see
+	// min_utilities.go; the threshold of an itemset is then the Aggregate of
+	// those of its items. It is ignored in top-k mode.
+	MinUtilities map[int]float64
+	Aggregate    Aggregate
+
+	// positiveItems are the dataset items with a positive utility before
+	// applyItemConstraints dropped transactions.
+	positiveItems map[int]bool
+
+	// Closed reports only the closed HUIs, see closed.go: ClosedItemsets
+	// holds them with their support and generators, and HighUtilityItemsets
+	// their itemsets and utilities. It is ignored in top-k and maximal mode.
+	Closed         bool
+	ClosedItemsets []*models.ClosedItemset
+
+	// Stats describes the last run.
+	Stats Stats
+
+	// Logger receives progress at info level and per-candidate traces at
+	// debug level. NewEMHUN sets a logger that discards everything.
+	Logger *slog.Logger
+
+	datasetItems *datasetItems
 }
 
 func NewEMHUN(transactions []*models.Transaction, minUtility float64) *EMHUN {
-	utilityArray := models.NewUtilityArray()
+	utilityArray := models.NewUtilityArray(0)
 	return &EMHUN{
 		Transactions:     transactions,
 		MinUtility:       minUtility,
+		Engine:           EngineProjection,
 		Rho:              make(map[int]bool),
 		Delta:            make(map[int]bool),
 		Eta:              make(map[int]bool),
 		UtilityArray:     utilityArray,
 		SearchAlgorithms: NewSearchAlgorithms(utilityArray),
+		Logger:           utility.DiscardLogger(),
 	}
 }
 
+// NewEMHUNWithRatio resolves minUtilityRatio against the total positive
+// utility of the transactions and uses the result as the absolute threshold.
+func NewEMHUNWithRatio(transactions []*models.Transaction, minUtilityRatio float64) *EMHUN {
+	e := NewEMHUN(transactions, minUtilityRatio*utility.CalculateTotalRTU(transactions))
+	e.MinUtilityRatio = minUtilityRatio
+	return e
+}
+
 func (e *EMHUN) Run() {
+	e.RunContext(context.Background())
+}
 
-	fmt.Println("Running EMHUN...")
+// RunContext runs EMHUN until the search completes, ctx is done or one of
+// e.Limits is hit. In the last two cases the itemsets found so far are kept in
+// SearchAlgorithms.HighUtilityItemsets and the returned status (also stored in
+// e.Status) is incomplete with the reason.
+func (e *EMHUN) RunContext(ctx context.Context) RunStatus {
+	// Các bước bên dưới thay e.Transactions bằng bản sao đã lọc và mã hóa;
+	// trả lại giao dịch của người gọi để có thể chạy lại
+	input := e.Transactions
+	defer func() {
+		e.Transactions = input
+		e.restoreItemIDs()
+	}()
+	e.reset()
+
+	control := newSearchControl(ctx, e.Limits)
+	e.SearchAlgorithms.control = control
+	if e.Logger == nil {
+		e.Logger = utility.DiscardLogger()
+	}
+	e.SearchAlgorithms.Logger = e.Logger
+	e.Stats = Stats{Transactions: len(e.Transactions)}
+	timer := newPhaseTimer()
+	control.peak.sample()
+
+	e.Logger.Info("running EMHUN", "transactions", len(e.Transactions), "minUtility", e.MinUtility, "engine", e.Engine)
+	if e.Exact {
+		if err := utility.CheckExact(e.Transactions); err != nil {
+			e.Status = RunStatus{Reason: StopInvalidInput, Err: err}
+			e.Logger.Error("refusing to run in exact mode", "error", err)
+			return e.Status
+		}
+	}
 
+	e.SearchAlgorithms.maximal = e.SearchAlgorithms.newMaximalSet()
+	if e.Closed && e.SearchAlgorithms.K == 0 && e.SearchAlgorithms.maximal == nil {
+		e.SearchAlgorithms.closed = newClosedSet(e.MinUtility)
+	}
+	e.applyItemConstraints()
+	e.encodeItems()
 	e.ClassifyItems()
-
-	fmt.Println("\nAfter classify, we have:")
 	e.printClassification()
+	e.Stats.RhoItems, e.Stats.DeltaItems, e.Stats.EtaItems = len(e.Rho), len(e.Delta), len(e.Eta)
+	e.Stats.Phases.Classify = timer.lap()
 
-	fmt.Println("\nCalculating RTWU for all items in (ρ ∪ δ):")
 	utility.CalculateRTWUForAllItems(e.Transactions, e.Rho, e.Delta, e.Eta, e.UtilityArray)
 
 	combinedSet := e.unionKeys(e.Rho, e.Delta)
@@ -48,9 +142,14 @@ func (e *EMHUN) Run() {
 
 	e.SortedSecondary = e.sortItems(secondaryItems)
 	e.SortedEta = e.sortItems(e.keys(e.Eta))
+	e.Stats.SecondaryItems = len(e.SortedSecondary)
+	e.Stats.Phases.RTWU = timer.lap()
 
 	secondaryItemsMap := convertSliceToMap(e.SortedSecondary)
 	e.FilterTransactions(secondaryItemsMap, e.Eta)
+	e.renameItemsInProcessingOrder()
+	satisfiable := e.resolveConstraints()
+	e.setItemMinUtilities()
 
 	e.SortItemsInTransactions()
 	// e.PrintTransactions()
@@ -59,18 +158,80 @@ func (e *EMHUN) Run() {
 	e.SortTransactionsByTWU()
 	// fmt.Println("\nTransactions after sorting by RTWU:")
 	// e.PrintTransactions()
+	for _, transaction := range e.Transactions {
+		if len(transaction.Items) > 0 {
+			e.Stats.FilteredTransactions++
+		}
+	}
+	e.Stats.Phases.Filter = timer.lap()
+
 	// fmt.Println("\nCalculating RSU for each item in Secondary(X)...")
 	utility.CalculateRSUForAllItems(e.Transactions, e.SortedSecondary, e.UtilityArray)
 	e.identifyPrimaryItems()
-	fmt.Println("Primary:", e.PrimaryItems)
-	fmt.Println("\nStarting HUI Search...")
-	e.SearchAlgorithms.Search(e.SortedEta, make(map[int]bool), e.Transactions, e.PrimaryItems, e.SortedSecondary, e.MinUtility)
-
-	// In kết quả sau khi tìm High Utility Itemsets
-	fmt.Println("\nHUIs Found:")
-	for _, hui := range e.SearchAlgorithms.HighUtilityItemsets {
-		fmt.Printf("Itemset: %v, Utility: %.2f\n", hui.Itemset, hui.Utility)
+	e.Stats.PrimaryItems = len(e.PrimaryItems)
+	e.Logger.Debug("primary items", "items", e.PrimaryItems)
+	control.checkContext()
+	if e.Engine == EngineUtilityList {
+		e.SearchAlgorithms.BuildUtilityLists(e.Transactions)
 	}
+	control.peak.sample()
+	e.Stats.Phases.RSU = timer.lap()
+
+	e.Logger.Info("starting HUI search", "primary", len(e.PrimaryItems), "secondary", len(e.SortedSecondary), "eta", len(e.SortedEta))
+	switch {
+	case !satisfiable:
+		e.Logger.Info("no itemset can meet the item constraints")
+	case e.Workers > 1:
+		e.runParallel()
+	case e.Engine == EngineUtilityList:
+		e.SearchAlgorithms.SearchUtilityList(e.SortedEta, nil, nil, e.PrimaryItems, e.SortedSecondary, e.MinUtility)
+	default:
+		e.SearchAlgorithms.Search(e.SortedEta, make(map[int]bool), e.Transactions, e.PrimaryItems, e.SortedSecondary, e.MinUtility)
+	}
+
+	if e.SearchAlgorithms.K > 0 {
+		e.SearchAlgorithms.HighUtilityItemsets = e.SearchAlgorithms.topKResults()
+		e.MinUtility = e.SearchAlgorithms.threshold(e.MinUtility)
+	} else if e.SearchAlgorithms.maximal != nil {
+		e.SearchAlgorithms.HighUtilityItemsets = limitResult(control, e.SearchAlgorithms.maximalResults())
+	} else if e.SearchAlgorithms.closed != nil {
+		e.ClosedItemsets = limitResult(control, e.SearchAlgorithms.closedResults())
+		e.SearchAlgorithms.HighUtilityItemsets = closedHUIs(e.ClosedItemsets)
+		e.Stats.ClosedItemsets = len(e.ClosedItemsets)
+	}
+	e.Stats.Phases.Search = timer.lap()
+	e.Stats.Phases.Total = timer.total()
+	e.collectSearchStats(control)
+
+	// Ghi log kết quả sau khi tìm High Utility Itemsets
+	if e.SearchAlgorithms.tracing() {
+		for _, hui := range e.SearchAlgorithms.HighUtilityItemsets {
+			e.Logger.Debug("HUI", "itemset", hui.Itemset, "utility", hui.Utility)
+		}
+	}
+
+	e.Status = control.status()
+	e.Logger.Info("search finished", "huis", len(e.SearchAlgorithms.HighUtilityItemsets), "complete", e.Status.Complete, "reason", e.Status.Reason)
+	return e.Status
+}
+
+// reset clears what a previous run left in e and e.SearchAlgorithms.
+func (e *EMHUN) reset() {
+	e.Rho = make(map[int]bool)
+	e.Delta = make(map[int]bool)
+	e.Eta = make(map[int]bool)
+	e.SortedSecondary, e.SortedEta, e.PrimaryItems = nil, nil, nil
+	e.datasetItems = nil
+
+	s := e.SearchAlgorithms
+	s.Beta = make(map[int]bool)
+	s.ItemList, s.FilteredPrimary, s.FilteredSecondary = nil, nil, nil
+	s.HighUtilityItemsets = []*models.HighUtilityItemset{}
+	s.ItemLists, s.ItemNames = nil, nil
+	s.topK = nil
+	s.maximal, s.closed, s.transactions = nil, nil, nil
+	e.ClosedItemsets = nil
+	s.counters = searchCounters{}
 }
 
 func (e *EMHUN) PrintTransactions() {
@@ -122,21 +283,20 @@ func (e *EMHUN) printClassification() {
 	sort.Ints(deltaItems)
 	sort.Ints(etaItems)
 
-	// fmt.Println("Items with positive utility only (ρ):", rhoItems)
-	// fmt.Println("Items with both positive and negative utility (δ):", deltaItems)
-	// fmt.Println("Items with negative utility only (η):", etaItems)
+	e.Logger.Debug("classified items", "rho", rhoItems, "delta", deltaItems, "eta", etaItems)
 }
 
 func (e *EMHUN) getSecondaryItems(combinedSet map[int]bool, utilityArray *models.UtilityArray, minU float64) []int {
 	var secondary []int
+	lowest := e.lowestMinUtility(e.Rho, e.Delta, e.Eta)
 	for item := range combinedSet {
 		rlu := utilityArray.GetRTWU(item)
-		if rlu >= minU {
+		if rlu >= e.secondaryThreshold(item, lowest, minU) {
 			secondary = append(secondary, item)
 		}
 	}
 	sort.Ints(secondary)
-	fmt.Printf("Secondary(X) items: %v\n", secondary)
+	e.Logger.Debug("secondary items", "items", secondary)
 	return secondary
 }
 
@@ -152,7 +312,11 @@ func (e *EMHUN) sortItems(items []int) []int {
 		rtwuI := e.UtilityArray.GetRTWU(items[i])
 		rtwuJ := e.UtilityArray.GetRTWU(items[j])
 
-		return rtwuI < rtwuJ
+		if rtwuI != rtwuJ {
+			return rtwuI < rtwuJ
+		}
+		// Phá hòa theo mã item để thứ tự trong giao dịch khớp với Secondary
+		return items[i] < items[j]
 	})
 
 	return items
@@ -183,16 +347,17 @@ func (e *EMHUN) FilterTransactions(secondaryItems map[int]bool, etaItems map[int
 
 func (e *EMHUN) SortItemsInTransactions() {
 	for _, transaction := range e.Transactions {
+		// Item lặp lại trong cùng giao dịch được gộp lại bằng tổng utility
 		itemUtilityMap := make(map[int]float64) // Sửa giá trị map từ int thành float64
 		for i, item := range transaction.Items {
-			itemUtilityMap[item] = transaction.Utilities[i]
+			itemUtilityMap[item] += transaction.Utilities[i]
 		}
 
 		var positiveItems []int
 		var hybridItems []int
 		var negativeItems []int
 
-		for _, item := range transaction.Items {
+		for item := range itemUtilityMap {
 			if e.Rho[item] {
 				positiveItems = append(positiveItems, item)
 			} else if e.Delta[item] {
@@ -219,8 +384,6 @@ func (e *EMHUN) SortItemsInTransactions() {
 }
 
 func (e *EMHUN) SortTransactionsByTWU() {
-	fmt.Println("\nSorting transactions by total RLU of items...")
-
 	sort.Slice(e.Transactions, func(i, j int) bool {
 		tuI := utility.CalculateTransactionUtility(e.Transactions[i])
 		tuJ := utility.CalculateTransactionUtility(e.Transactions[j])
@@ -232,14 +395,20 @@ func (e *EMHUN) SortTransactionsByTWU() {
 
 func (e *EMHUN) sortItemsByRTWU(items []int) []int {
 	sort.Slice(items, func(i, j int) bool {
-		return e.UtilityArray.GetRTWU(items[i]) < e.UtilityArray.GetRTWU(items[j])
+		rtwuI := e.UtilityArray.GetRTWU(items[i])
+		rtwuJ := e.UtilityArray.GetRTWU(items[j])
+		if rtwuI != rtwuJ {
+			return rtwuI < rtwuJ
+		}
+		return items[i] < items[j]
 	})
 	return items
 }
 
 func (e *EMHUN) identifyPrimaryItems() {
 	for _, item := range e.SortedSecondary {
-		if e.UtilityArray.GetRSU(item) >= e.MinUtility {
+		// RSU của item bao các itemset bắt đầu bằng item
+		if e.UtilityArray.GetRSU(item) >= e.SearchAlgorithms.extensionThreshold(itemThresholds{}, item, item+1, e.MinUtility) {
 			e.PrimaryItems = append(e.PrimaryItems, item)
 		}
 	}
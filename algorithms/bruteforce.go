@@ -0,0 +1,76 @@
+package algorithms
+
+import (
+	"emhun/models"
+	"fmt"
+	"slices"
+)
+
+// maxBruteForceItems bounds the number of distinct items BruteForce accepts;
+// it enumerates 2^n itemsets.
+const maxBruteForceItems = 24
+
+// BruteForce returns every itemset whose utility is at least minU, found by
+// enumerating all subsets of the items and computing their exact utility. It
+// is a reference for testing EMHUN on small datasets. As in EMHUN, an item
+// repeated within a transaction counts with the sum of its utilities.
+// Itemsets are returned in canonical order: items ascending, itemsets sorted
+// by their items.
+func BruteForce(transactions []*models.Transaction, minU float64) ([]*models.HighUtilityItemset, error) {
+	var items []int
+	index := make(map[int]int)
+	for _, transaction := range transactions {
+		for _, item := range transaction.Items {
+			if _, ok := index[item]; !ok {
+				index[item] = -1
+				items = append(items, item)
+			}
+		}
+	}
+	if len(items) > maxBruteForceItems {
+		return nil, fmt.Errorf("brute force: %d distinct items, at most %d supported", len(items), maxBruteForceItems)
+	}
+	slices.Sort(items)
+	for i, item := range items {
+		index[item] = i
+	}
+
+	// Mỗi giao dịch được biểu diễn bằng bitmask các item và utility theo chỉ số
+	masks := make([]uint32, len(transactions))
+	utilities := make([][]float64, len(transactions))
+	for t, transaction := range transactions {
+		utilities[t] = make([]float64, len(items))
+		for i, item := range transaction.Items {
+			masks[t] |= 1 << index[item]
+			utilities[t][index[item]] += transaction.Utilities[i]
+		}
+	}
+
+	var result []*models.HighUtilityItemset
+	for mask := uint32(1); mask < 1<<len(items); mask++ {
+		total := 0.0
+		for t := range transactions {
+			if masks[t]&mask != mask {
+				continue
+			}
+			for i := range items {
+				if mask&(1<<i) != 0 {
+					total += utilities[t][i]
+				}
+			}
+		}
+		if total >= minU {
+			var itemset []int
+			for i, item := range items {
+				if mask&(1<<i) != 0 {
+					itemset = append(itemset, item)
+				}
+			}
+			result = append(result, models.NewHighUtilityItemset(itemset, total))
+		}
+	}
+	slices.SortFunc(result, func(a, b *models.HighUtilityItemset) int {
+		return slices.Compare(a.Itemset, b.Itemset)
+	})
+	return result, nil
+}
@@ -0,0 +1,294 @@
+package algorithms
+
+import (
+	"cmp"
+	"emhun/models"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// In closed mode (EMHUN.Closed) only the closed HUIs are reported: the HUIs
+// without a proper superset contained in the same transactions. Each HUI the
+// search finds is mapped to its closure, the items common to every
+// transaction containing it, computed from the transactions the search
+// already holds for that node. HUIs with the same closure share their
+// transactions, so a closure is kept once, with the utility of each of its
+// items and its generators, the minimal HUIs found with that closure.
+//
+// With negative items, or with per-item thresholds that the added items
+// raise, a closure can fall below its threshold while some of its subsets
+// are HUIs; such a closure is not a HUI and is not reported.
+//
+// Closures are computed on the filtered transactions. An item dropped by
+// FilterTransactions has an RTWU below the threshold, the lowest one with
+// per-item thresholds (see secondaryThreshold), while an item in every
+// transaction of a HUI has an RTWU of at least its utility, so no closure of
+// a HUI loses an item.
+
+// closedClass is one closure, as ascending dense ids.
+type closedClass struct {
+	itemset    []int
+	utilities  []float64
+	support    int
+	generators [][]int
+}
+
+// closedSet holds the closures found by one worker whose utility reaches
+// their threshold: minU, or with EMHUN.MinUtilities their own one.
+type closedSet struct {
+	minU    float64
+	classes map[string]*closedClass
+}
+
+func newClosedSet(minU float64) *closedSet {
+	return &closedSet{minU: minU, classes: make(map[string]*closedClass)}
+}
+
+// add records generator, a HUI as ascending dense ids, under the closure
+// computed by close, or drops it if close returns nil. Generators containing
+// another one of the same closure are dropped, so the result does not depend
+// on the order of the insertions.
+func (c *closedSet) add(generator []int, close func() *closedClass) {
+	class, ok := c.classes[itemsetKey(generator)]
+	if !ok {
+		class = close()
+		if class == nil {
+			return
+		}
+		key := itemsetKey(class.itemset)
+		if known, ok := c.classes[key]; ok {
+			class = known
+		} else {
+			c.classes[key] = class
+		}
+	}
+	class.addGenerator(generator)
+}
+
+func (class *closedClass) addGenerator(generator []int) {
+	for _, g := range class.generators {
+		if isSubset(g, generator) {
+			return
+		}
+	}
+	class.generators = slices.DeleteFunc(class.generators, func(g []int) bool {
+		return isSubset(generator, g)
+	})
+	class.generators = append(class.generators, generator)
+}
+
+// merge adds the closures of other, e.g. those found by another worker.
+func (c *closedSet) merge(other *closedSet) {
+	for key, class := range other.classes {
+		known, ok := c.classes[key]
+		if !ok {
+			c.classes[key] = class
+			continue
+		}
+		for _, g := range class.generators {
+			known.addGenerator(g)
+		}
+	}
+}
+
+// closure returns the closure of the itemset contained in transactions, or
+// in the transactions of list for the utility-list engine.
+func (c *closedSet) closure(transactions []*models.Transaction, tids []int) *closedClass {
+	counts := make(map[int]int)
+	utilities := make(map[int]float64)
+	support := 0
+	visit := func(transaction *models.Transaction) {
+		support++
+		for i, item := range transaction.Items {
+			counts[item]++
+			utilities[item] += transaction.Utilities[i]
+		}
+	}
+	if tids != nil {
+		for _, tid := range tids {
+			visit(transactions[tid])
+		}
+	} else {
+		for _, transaction := range transactions {
+			visit(transaction)
+		}
+	}
+
+	class := &closedClass{support: support}
+	for item, count := range counts {
+		if count == support {
+			class.itemset = append(class.itemset, item)
+		}
+	}
+	slices.Sort(class.itemset)
+	class.utilities = make([]float64, len(class.itemset))
+	for i, item := range class.itemset {
+		class.utilities[i] = utilities[item]
+	}
+	return class
+}
+
+func (class *closedClass) utility() float64 {
+	total := 0.0
+	for _, u := range class.utilities {
+		total += u
+	}
+	return total
+}
+
+// addClosed records a HUI found in closed mode. The transactions containing
+// itemset are given either as transactions or as the tids of list.
+func (s *SearchAlgorithms) addClosed(itemset []int, transactions []*models.Transaction, list *models.UtilityList) {
+	s.closed.add(slices.Sorted(slices.Values(itemset)), func() *closedClass {
+		var class *closedClass
+		if list == nil {
+			class = s.closed.closure(transactions, nil)
+		} else {
+			tids := make([]int, len(list.Entries))
+			for i, entry := range list.Entries {
+				tids[i] = entry.Tid
+			}
+			class = s.closed.closure(s.transactions, tids)
+		}
+		// Bao đóng dưới ngưỡng của chính nó không phải HUI nên không được báo cáo
+		if class.utility() < s.huiThreshold(s.thresholdsOf(class.itemset), s.closed.minU) {
+			return nil
+		}
+		return class
+	})
+}
+
+// closedResults returns the closed HUIs with dataset ids, items and
+// generators in ascending order, sorted by itemset.
+func (s *SearchAlgorithms) closedResults() []*models.ClosedItemset {
+	ascending := func(items []int) []int {
+		names := make([]int, len(items))
+		for i, item := range items {
+			names[i] = s.itemName(item)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	closed := make([]*models.ClosedItemset, 0, len(s.closed.classes))
+	for _, class := range s.closed.classes {
+		// Sắp xếp lại các item theo mã trong dataset, kèm utility của chúng
+		order := make([]int, len(class.itemset))
+		for i := range order {
+			order[i] = i
+		}
+		slices.SortFunc(order, func(a, b int) int {
+			return cmp.Compare(s.itemName(class.itemset[a]), s.itemName(class.itemset[b]))
+		})
+		c := &models.ClosedItemset{Support: class.support}
+		for _, i := range order {
+			c.Itemset = append(c.Itemset, s.itemName(class.itemset[i]))
+			c.ItemUtilities = append(c.ItemUtilities, class.utilities[i])
+			c.Utility += class.utilities[i]
+		}
+		for _, g := range class.generators {
+			c.Generators = append(c.Generators, ascending(g))
+		}
+		slices.SortFunc(c.Generators, slices.Compare)
+		closed = append(closed, c)
+	}
+	slices.SortFunc(closed, func(a, b *models.ClosedItemset) int {
+		return slices.Compare(a.Itemset, b.Itemset)
+	})
+	return closed
+}
+
+// closedHUIs returns the itemsets and utilities of closed.
+func closedHUIs(closed []*models.ClosedItemset) []*models.HighUtilityItemset {
+	huis := make([]*models.HighUtilityItemset, len(closed))
+	for i, c := range closed {
+		huis[i] = models.NewHighUtilityItemset(c.Itemset, c.Utility)
+	}
+	return huis
+}
+
+func itemsetKey(items []int) string {
+	var b strings.Builder
+	for _, item := range items {
+		b.WriteString(strconv.Itoa(item))
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// isSubset reports whether a ⊆ b, both in ascending order.
+func isSubset(a, b []int) bool {
+	j := 0
+	for _, item := range a {
+		for j < len(b) && b[j] < item {
+			j++
+		}
+		if j == len(b) || b[j] != item {
+			return false
+		}
+		j++
+	}
+	return true
+}
+
+// RecoverHUIs returns every itemset with utility at least minU that has one
+// of the closures in closed, in canonical order. Given the closed itemsets of
+// a run with threshold t, RecoverHUIs(closed, t) returns the HUIs of that run
+// whose closure is a HUI too; a HUI whose closure fell below t, which only
+// happens with negative items, is not recovered. A larger minU gives these
+// HUIs for that threshold.
+func RecoverHUIs(closed []*models.ClosedItemset, minU float64) []*models.HighUtilityItemset {
+	var result []*models.HighUtilityItemset
+	for _, c := range closed {
+		// Các generator được biểu diễn bằng vị trí trong c.Itemset
+		generators := make([][]int, len(c.Generators))
+		for g, generator := range c.Generators {
+			for _, item := range generator {
+				i, _ := slices.BinarySearch(c.Itemset, item)
+				generators[g] = append(generators[g], i)
+			}
+		}
+		remaining := make([]float64, len(c.Itemset)+1)
+		for i := len(c.Itemset) - 1; i >= 0; i-- {
+			remaining[i] = remaining[i+1] + max(0, c.ItemUtilities[i])
+		}
+
+		selected := make([]bool, len(c.Itemset))
+		var visit func(i int, u float64)
+		visit = func(i int, u float64) {
+			// Cận trên: chỉ các item còn lại có utility dương mới làm tăng u
+			if u+remaining[i] < minU {
+				return
+			}
+			if i == len(c.Itemset) {
+				if u >= minU && slices.ContainsFunc(generators, func(g []int) bool {
+					for _, j := range g {
+						if !selected[j] {
+							return false
+						}
+					}
+					return true
+				}) {
+					var itemset []int
+					for j, ok := range selected {
+						if ok {
+							itemset = append(itemset, c.Itemset[j])
+						}
+					}
+					result = append(result, models.NewHighUtilityItemset(itemset, u))
+				}
+				return
+			}
+			selected[i] = true
+			visit(i+1, u+c.ItemUtilities[i])
+			selected[i] = false
+			visit(i+1, u)
+		}
+		visit(0, 0)
+	}
+	slices.SortFunc(result, func(a, b *models.HighUtilityItemset) int {
+		return slices.Compare(a.Itemset, b.Itemset)
+	})
+	return result
+}
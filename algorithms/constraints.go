@@ -0,0 +1,243 @@
+package algorithms
+
+import (
+	"emhun/models"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+// NoNegativeItems as Constraints.MaxNegativeItems excludes every η item.
+const NoNegativeItems = -1
+
+// Constraints restrict the reported itemsets. They are pushed into the
+// search: itemsets are not extended past MaxLength or past MaxNegativeItems η
+// items, while prefixes shorter than MinLength are still explored but not
+// reported. Zero values mean no constraint.
+type Constraints struct {
+	MinLength int
+	MaxLength int
+	// MaxNegativeItems caps the number of η (negative-only) items in an
+	// itemset; use NoNegativeItems to allow none.
+	MaxNegativeItems int
+
+	// Item constraints, as dataset item ids: an itemset must contain every
+	// item of MustContain, none of MustNotContain and, if AnyOf is not empty,
+	// at least one item of AnyOf. MustNotContain items are removed from the
+	// transactions before mining, and transactions that cannot support an
+	// allowed itemset are dropped.
+	MustContain    []int
+	MustNotContain []int
+	AnyOf          []int
+}
+
+var ErrConstraints = errors.New("invalid constraints")
+
+func (c Constraints) Validate() error {
+	switch {
+	case c.MinLength < 0 || c.MaxLength < 0:
+		return fmt.Errorf("%w: lengths must not be negative", ErrConstraints)
+	case c.MaxLength > 0 && c.MinLength > c.MaxLength:
+		return fmt.Errorf("%w: min length %d is greater than max length %d", ErrConstraints, c.MinLength, c.MaxLength)
+	case c.MaxNegativeItems < NoNegativeItems:
+		return fmt.Errorf("%w: max negative items must be at least %d", ErrConstraints, NoNegativeItems)
+	}
+	for _, item := range c.MustContain {
+		if slices.Contains(c.MustNotContain, item) {
+			return fmt.Errorf("%w: item %d is both required and excluded", ErrConstraints, item)
+		}
+	}
+	if len(c.AnyOf) > 0 && !slices.ContainsFunc(c.AnyOf, func(item int) bool { return !slices.Contains(c.MustNotContain, item) }) {
+		return fmt.Errorf("%w: every item of any-of is excluded", ErrConstraints)
+	}
+	return nil
+}
+
+// IsZero reports whether c restricts nothing.
+func (c Constraints) IsZero() bool {
+	return c.MinLength == 0 && c.MaxLength == 0 && c.MaxNegativeItems == 0 &&
+		len(c.MustContain) == 0 && len(c.MustNotContain) == 0 && len(c.AnyOf) == 0
+}
+
+// allowsItems reports whether itemset, as dataset ids, meets the item
+// constraints.
+func (c Constraints) allowsItems(itemset []int) bool {
+	for _, item := range c.MustContain {
+		if !slices.Contains(itemset, item) {
+			return false
+		}
+	}
+	for _, item := range itemset {
+		if slices.Contains(c.MustNotContain, item) {
+			return false
+		}
+	}
+	return len(c.AnyOf) == 0 || slices.ContainsFunc(itemset, func(item int) bool { return slices.Contains(c.AnyOf, item) })
+}
+
+// applyItemConstraints removes the MustNotContain items from the transactions
+// and empties those missing a MustContain item or without any AnyOf item,
+// working on copies as encodeItems does. It runs on dataset ids, before
+// encodeItems, so the RTWU of the remaining items only counts transactions
+// that can support an allowed itemset.
+func (e *EMHUN) applyItemConstraints() {
+	c := e.Constraints
+	e.positiveItems = nil
+	if len(c.MustContain) == 0 && len(c.MustNotContain) == 0 && len(c.AnyOf) == 0 {
+		return
+	}
+	// Bỏ giao dịch có thể biến một item δ thành η; MaxNegativeItems vẫn phải
+	// đếm theo phân lớp trên toàn bộ dataset
+	e.positiveItems = make(map[int]bool)
+	for _, transaction := range e.Transactions {
+		for i, item := range transaction.Items {
+			if transaction.Utilities[i] > 0 {
+				e.positiveItems[item] = true
+			}
+		}
+	}
+	filtered := make([]*models.Transaction, len(e.Transactions))
+	for t, transaction := range e.Transactions {
+		var items []int
+		var utilities []float64
+		for i, item := range transaction.Items {
+			if !slices.Contains(c.MustNotContain, item) {
+				items = append(items, item)
+				utilities = append(utilities, transaction.Utilities[i])
+			}
+		}
+		keep := len(c.AnyOf) == 0 || slices.ContainsFunc(items, func(item int) bool { return slices.Contains(c.AnyOf, item) })
+		for _, item := range c.MustContain {
+			if !slices.Contains(items, item) {
+				keep = false
+			}
+		}
+		if !keep {
+			items, utilities = nil, nil
+		}
+		filtered[t] = models.NewTransaction(items, utilities, transaction.TransactionUtility)
+	}
+	e.Transactions = filtered
+}
+
+// resolveConstraints passes the constraints to the search with the ids set
+// by renameItemsInProcessingOrder. It reports false if no itemset can satisfy
+// them, because a required item or every AnyOf item was pruned.
+func (e *EMHUN) resolveConstraints() bool {
+	s := e.SearchAlgorithms
+	s.constraints = e.Constraints
+	s.firstEta = len(e.SortedSecondary)
+	s.negative, s.mixedEta = nil, false
+	if e.positiveItems != nil {
+		s.negative = make([]bool, len(s.ItemNames))
+		for id := s.firstEta; id < len(s.ItemNames); id++ {
+			s.negative[id] = !e.positiveItems[s.ItemNames[id]]
+			s.mixedEta = s.mixedEta || !s.negative[id]
+		}
+	}
+
+	s.required, s.anyOf = nil, nil
+	ids := make(map[int]int, len(s.ItemNames))
+	for id, name := range s.ItemNames {
+		ids[name] = id
+	}
+	for _, item := range e.Constraints.MustContain {
+		id, ok := ids[item]
+		if !ok {
+			return false
+		}
+		s.required = append(s.required, id)
+	}
+	for _, item := range e.Constraints.AnyOf {
+		if id, ok := ids[item]; ok {
+			s.anyOf = append(s.anyOf, id)
+		}
+	}
+	if len(e.Constraints.AnyOf) > 0 && len(s.anyOf) == 0 {
+		return false
+	}
+	slices.Sort(s.required)
+	slices.Sort(s.anyOf)
+	return true
+}
+
+// reachable reports whether itemset, extended only with items from next on,
+// can still meet MustContain and AnyOf. Extensions come in processing order,
+// so a node whose required items lie before its last item has no allowed
+// itemset below it; with next = math.MaxInt it tells whether itemset itself
+// meets them.
+func (s *SearchAlgorithms) reachable(itemset []int, next int) bool {
+	for _, item := range s.required {
+		if item < next && !slices.Contains(itemset, item) {
+			return false
+		}
+	}
+	if len(s.anyOf) == 0 || s.anyOf[len(s.anyOf)-1] >= next {
+		return true
+	}
+	return slices.ContainsFunc(itemset, func(item int) bool {
+		_, found := slices.BinarySearch(s.anyOf, item)
+		return found
+	})
+}
+
+// pruneByConstraints reports whether the node beta, whose last item is item,
+// has no allowed itemset in its subtree. Extensions never remove η items, so
+// a node over MaxNegativeItems is pruned too.
+func (s *SearchAlgorithms) pruneByConstraints(beta []int, item int) bool {
+	if s.reachable(beta, item) && s.constraints.negativeAllowed(s.negativeItems(beta)) {
+		return false
+	}
+	s.counters.prunedConstraints++
+	return true
+}
+
+// allowed reports whether a found HUI meets the constraints.
+func (s *SearchAlgorithms) allowed(itemset []int) bool {
+	return s.constraints.allows(len(itemset), s.negativeItems(itemset)) && s.reachable(itemset, math.MaxInt)
+}
+
+// allows reports whether an itemset of the given size with negatives η items
+// may be reported.
+func (c Constraints) allows(size, negatives int) bool {
+	if size < c.MinLength || c.MaxLength > 0 && size > c.MaxLength {
+		return false
+	}
+	return c.negativeAllowed(negatives)
+}
+
+func (c Constraints) negativeAllowed(negatives int) bool {
+	switch c.MaxNegativeItems {
+	case 0:
+		return true
+	case NoNegativeItems:
+		return negatives == 0
+	}
+	return negatives <= c.MaxNegativeItems
+}
+
+// negativeItems counts the η items of itemset. After
+// renameItemsInProcessingOrder they are the ids from firstEta on, except for
+// those that negative marks as δ in the whole dataset.
+func (s *SearchAlgorithms) negativeItems(itemset []int) int {
+	n := 0
+	for _, item := range itemset {
+		if item >= s.firstEta && (s.negative == nil || s.negative[item]) {
+			n++
+		}
+	}
+	return n
+}
+
+// canExtendNegative reports whether one more η item may be added to itemset.
+// η items come last in processing order, so after one only η items follow.
+func (s *SearchAlgorithms) canExtendNegative(itemset []int) bool {
+	if !s.reachable(itemset, s.firstEta) {
+		return false
+	}
+	if s.constraints.MaxNegativeItems == 0 || s.mixedEta {
+		return true
+	}
+	return s.constraints.negativeAllowed(s.negativeItems(itemset) + 1)
+}
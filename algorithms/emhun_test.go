@@ -0,0 +1,872 @@
+package algorithms
+
+import (
+	"context"
+	"emhun/dataset"
+	"emhun/models"
+	"emhun/utility"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+)
+
+func cloneTransactions(transactions []*models.Transaction) []*models.Transaction {
+	clones := make([]*models.Transaction, len(transactions))
+	for i, t := range transactions {
+		clones[i] = models.NewTransaction(slices.Clone(t.Items), slices.Clone(t.Utilities), t.TransactionUtility)
+	}
+	return clones
+}
+
+// canonical maps each itemset, with its items sorted, to its utility.
+func canonical(huis []*models.HighUtilityItemset) map[string]float64 {
+	result := make(map[string]float64, len(huis))
+	for _, hui := range huis {
+		result[fmt.Sprint(slices.Sorted(slices.Values(hui.Itemset)))] = hui.Utility
+	}
+	return result
+}
+
+func compareHUIs(t *testing.T, got, want []*models.HighUtilityItemset) {
+	t.Helper()
+	gotSet, wantSet := canonical(got), canonical(want)
+	if len(got) != len(gotSet) {
+		t.Errorf("%d itemsets reported, %d distinct", len(got), len(gotSet))
+	}
+	for itemset, utility := range wantSet {
+		u, ok := gotSet[itemset]
+		if !ok {
+			t.Errorf("missing HUI %s with utility %g", itemset, utility)
+		} else if math.Abs(u-utility) > 1e-9*math.Max(1, math.Abs(utility)) {
+			t.Errorf("HUI %s has utility %g, want %g", itemset, u, utility)
+		}
+	}
+	for itemset, utility := range gotSet {
+		if _, ok := wantSet[itemset]; !ok {
+			t.Errorf("unexpected HUI %s with utility %g", itemset, utility)
+		}
+	}
+}
+
+// TestNegativeExtensionOfLowUtilityItemset checks that an itemset whose own
+// utility is below minU is still extended with η items: {1, 2} drops the
+// transaction where item 1 is negative.
+func TestNegativeExtensionOfLowUtilityItemset(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2}, []float64{10, -1}, 9),
+		models.NewTransaction([]int{1}, []float64{-10}, -10),
+	}
+	e := NewEMHUN(transactions, 9)
+	e.Run()
+	compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+		models.NewHighUtilityItemset([]int{1, 2}, 9),
+	})
+}
+
+func TestRunTopK(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2}, []float64{10, 1}, 11),
+		models.NewTransaction([]int{3}, []float64{4}, 4),
+		models.NewTransaction([]int{2, 3}, []float64{1, -1}, 0),
+	}
+	e := NewEMHUN(transactions, 0)
+	e.RunTopK(3)
+	compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+		models.NewHighUtilityItemset([]int{1, 2}, 11),
+		models.NewHighUtilityItemset([]int{1}, 10),
+		models.NewHighUtilityItemset([]int{3}, 3),
+	})
+	if e.MinUtility != 3 {
+		t.Errorf("MinUtility = %g after top-3, want 3", e.MinUtility)
+	}
+}
+
+// TestTopKRepeatedItem checks that an item repeated within a transaction
+// does not seed the threshold with a pair [x,x] or count its partner twice.
+func TestTopKRepeatedItem(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 1, 2}, []float64{5, 5, 1}, 11),
+		models.NewTransaction([]int{3}, []float64{4}, 4),
+	}
+	// Top-3 là [1 2]=11, [1]=10, [3]=4
+	if got := NewEMHUN(transactions, 0).seedTopKThreshold(3); got > 4 {
+		t.Errorf("seed threshold %g, want at most 4", got)
+	}
+}
+
+// itemIDsTransactions uses sparse dataset ids: 7 is ρ, 100 is δ, 42 is η, and
+// 9 is ρ with an RTWU of 7, so it is dropped at minU 10.
+func itemIDsTransactions() []*models.Transaction {
+	return []*models.Transaction{
+		models.NewTransaction([]int{7, 42, 100}, []float64{5, -2, 3}, 6),
+		models.NewTransaction([]int{7, 100}, []float64{4, -1}, 3),
+		models.NewTransaction([]int{100, 9}, []float64{6, 1}, 7),
+	}
+}
+
+// TestRunTwice checks that a run leaves the transactions unchanged and that
+// a second run on the same EMHUN gives the same HUIs.
+func TestRunTwice(t *testing.T) {
+	input := itemIDsTransactions()
+	for _, engine := range []Engine{EngineProjection, EngineUtilityList} {
+		transactions := cloneTransactions(input)
+		e := NewEMHUN(transactions, 10)
+		e.Engine = engine
+		e.Run()
+		first := e.SearchAlgorithms.HighUtilityItemsets
+		for i, transaction := range transactions {
+			if !slices.Equal(transaction.Items, input[i].Items) || !slices.Equal(transaction.Utilities, input[i].Utilities) {
+				t.Fatalf("engine %s: transaction %d changed to %v, was %v", engine, i, transaction, input[i])
+			}
+		}
+		e.Run()
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, first)
+		if len(first) == 0 {
+			t.Errorf("engine %s: first run found no HUIs", engine)
+		}
+	}
+}
+
+// TestItemFieldsUseDatasetIDs checks that the exported item fields hold the
+// dataset ids, not the dense ids used for mining, once a run is over.
+func TestItemFieldsUseDatasetIDs(t *testing.T) {
+	e := NewEMHUN(itemIDsTransactions(), 10)
+	e.Run()
+
+	for name, got := range map[string]map[int]bool{"Rho": e.Rho, "Delta": e.Delta, "Eta": e.Eta} {
+		want := map[string]map[int]bool{
+			"Rho":   {7: true, 9: true},
+			"Delta": {100: true},
+			"Eta":   {42: true},
+		}[name]
+		if !maps.Equal(got, want) {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	if want := []int{7, 100}; !slices.Equal(e.SortedSecondary, want) {
+		t.Errorf("SortedSecondary = %v, want %v", e.SortedSecondary, want)
+	}
+	if want := []int{42}; !slices.Equal(e.SortedEta, want) {
+		t.Errorf("SortedEta = %v, want %v", e.SortedEta, want)
+	}
+	for _, item := range e.PrimaryItems {
+		if !slices.Contains(e.SortedSecondary, item) {
+			t.Errorf("primary item %d is not in SortedSecondary", item)
+		}
+	}
+	for item, want := range map[int]float64{7: 12, 100: 19, 9: 7} {
+		if got := e.UtilityArray.GetRTWU(item); got != want {
+			t.Errorf("RTWU(%d) = %g, want %g", item, got, want)
+		}
+	}
+}
+
+// TestMaxHUIs checks that MaxHUIs only truncates runs with more itemsets
+// than the limit.
+func TestMaxHUIs(t *testing.T) {
+	full := NewEMHUN(itemIDsTransactions(), 5)
+	full.Run()
+	n := len(full.SearchAlgorithms.HighUtilityItemsets)
+	if n < 2 {
+		t.Fatalf("%d HUIs at minU 5, need at least 2", n)
+	}
+	for _, workers := range []int{1, 4} {
+		for _, engine := range []Engine{EngineProjection, EngineUtilityList} {
+			for _, tt := range []struct {
+				maxHUIs  int
+				want     int
+				complete bool
+			}{
+				{n, n, true},
+				{n - 1, n - 1, false},
+			} {
+				t.Run(fmt.Sprintf("%s/workers=%d/max=%d", engine, workers, tt.maxHUIs), func(t *testing.T) {
+					e := NewEMHUN(itemIDsTransactions(), 5)
+					e.Engine = engine
+					e.Workers = workers
+					e.Limits.MaxHUIs = tt.maxHUIs
+					status := e.RunContext(context.Background())
+					if got := len(e.SearchAlgorithms.HighUtilityItemsets); got != tt.want {
+						t.Errorf("%d itemsets, want %d", got, tt.want)
+					}
+					if status.Complete != tt.complete {
+						t.Errorf("complete = %t, want %t", status.Complete, tt.complete)
+					}
+					if !tt.complete && status.Reason != StopMaxHUIs {
+						t.Errorf("reason %q, want %q", status.Reason, StopMaxHUIs)
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestTopKMaxDepth checks that a pair the search cannot reach does not seed
+// the top-k threshold: with MaxDepth 1 the best three itemsets are singles.
+func TestTopKMaxDepth(t *testing.T) {
+	e := NewEMHUN([]*models.Transaction{
+		models.NewTransaction([]int{1, 2}, []float64{5, 5}, 10),
+		models.NewTransaction([]int{3}, []float64{4}, 4),
+		models.NewTransaction([]int{4}, []float64{3}, 3),
+	}, 0)
+	e.Limits.MaxDepth = 1
+	e.RunTopK(3)
+	compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+		models.NewHighUtilityItemset([]int{1}, 5),
+		models.NewHighUtilityItemset([]int{2}, 5),
+		models.NewHighUtilityItemset([]int{3}, 4),
+	})
+}
+
+// TestExactRejectsOverflow checks that exact mode runs on utilities adding
+// up to utility.MaxExactTotal and refuses one unit more.
+func TestExactRejectsOverflow(t *testing.T) {
+	const half = utility.MaxExactTotal / 2
+	tests := []struct {
+		last float64
+		err  error
+	}{
+		{0, nil},
+		{1, utility.ErrExactTotal},
+	}
+	for _, tt := range tests {
+		transactions := []*models.Transaction{
+			models.NewTransaction([]int{1, 2}, []float64{half, tt.last}, half+tt.last),
+			models.NewTransaction([]int{1}, []float64{half}, half),
+		}
+		e := NewEMHUN(transactions, half)
+		e.Exact = true
+		status := e.RunContext(context.Background())
+		if !errors.Is(status.Err, tt.err) || (status.Err == nil) != (tt.err == nil) {
+			t.Errorf("last utility %g: error %v, want %v", tt.last, status.Err, tt.err)
+		}
+		if tt.err == nil && (!status.Complete || len(e.SearchAlgorithms.HighUtilityItemsets) == 0) {
+			t.Errorf("run within the limit: status %+v, %d HUIs", status, len(e.SearchAlgorithms.HighUtilityItemsets))
+		}
+		if tt.err != nil && (status.Complete || status.Reason != StopInvalidInput || len(e.SearchAlgorithms.HighUtilityItemsets) != 0) {
+			t.Errorf("refused run: status %+v, %d HUIs", status, len(e.SearchAlgorithms.HighUtilityItemsets))
+		}
+	}
+}
+
+// engineConfigs are the search variants that must all find the same HUIs.
+var engineConfigs = []struct {
+	name       string
+	engine     Engine
+	workers    int
+	splitDepth int
+}{
+	{"projection", EngineProjection, 1, 0},
+	{"utility-list", EngineUtilityList, 1, 0},
+	{"projection/parallel", EngineProjection, 4, 2},
+	{"utility-list/parallel", EngineUtilityList, 4, 2},
+}
+
+// runAllConfigs mines a copy of transactions at minU once per engine config,
+// after configure has set up the mode under test, and calls check on each
+// finished run in a subtest name/config. Setting SearchAlgorithms.K in
+// configure runs top-k mode. A failed subtest logs minU and the transactions.
+func runAllConfigs(t *testing.T, name string, transactions []*models.Transaction, minU float64, configure func(*EMHUN), check func(*testing.T, *EMHUN)) {
+	t.Helper()
+	for _, config := range engineConfigs {
+		e := NewEMHUN(cloneTransactions(transactions), minU)
+		e.Engine = config.engine
+		e.Workers = config.workers
+		e.SplitDepth = config.splitDepth
+		if configure != nil {
+			configure(e)
+		}
+		if k := e.SearchAlgorithms.K; k > 0 {
+			e.RunTopK(k)
+		} else {
+			e.Run()
+		}
+		t.Run(name+"/"+config.name, func(t *testing.T) {
+			check(t, e)
+			if t.Failed() {
+				t.Logf("minU = %g", minU)
+				for _, transaction := range transactions {
+					t.Log(transaction)
+				}
+			}
+		})
+	}
+}
+
+// randomTransactions builds a small database where each item is positive,
+// hybrid or negative with equal probability.
+func randomTransactions(r *rand.Rand) []*models.Transaction {
+	itemCount := 3 + r.Intn(8)
+	kinds := make([]int, itemCount)
+	for i := range kinds {
+		kinds[i] = r.Intn(3)
+	}
+
+	var transactions []*models.Transaction
+	for range 3 + r.Intn(10) {
+		var items []int
+		var utilities []float64
+		for item, kind := range kinds {
+			if r.Intn(2) == 0 {
+				continue
+			}
+			u := float64(1 + r.Intn(10))
+			if kind == 2 || kind == 1 && r.Intn(2) == 0 {
+				u = -u
+			}
+			items = append(items, item+1)
+			utilities = append(utilities, u)
+		}
+		if len(items) == 0 {
+			continue
+		}
+		tu := 0.0
+		for _, u := range utilities {
+			tu += u
+		}
+		transactions = append(transactions, models.NewTransaction(items, utilities, tu))
+	}
+	return transactions
+}
+
+func TestBruteForceTable3(t *testing.T) {
+	data, err := dataset.ReadFile("../data/table3.txt", dataset.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := BruteForce(data.Transactions, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*models.HighUtilityItemset{
+		models.NewHighUtilityItemset([]int{2, 4, 5}, 31),
+		models.NewHighUtilityItemset([]int{3, 4}, 31),
+		models.NewHighUtilityItemset([]int{3, 4, 5}, 37),
+		models.NewHighUtilityItemset([]int{4, 5}, 37),
+	}
+	compareHUIs(t, got, want)
+}
+
+func TestEMHUNMatchesBruteForceTable3(t *testing.T) {
+	data, err := dataset.ReadFile("../data/table3.txt", dataset.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, minU := range []float64{1, 5, 10, 20, 25, 30, 37, 38} {
+		want, err := BruteForce(data.Transactions, minU)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runAllConfigs(t, fmt.Sprintf("minU=%g", minU), data.Transactions, minU, nil, func(t *testing.T, e *EMHUN) {
+			compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+		})
+	}
+}
+
+// oracleMode is a mining mode checked against BruteForce on random
+// databases. setup draws the parameters of the mode for one database mined
+// at minU, and returns how to configure EMHUN and how to check the run.
+type oracleMode struct {
+	name  string
+	setup func(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (configure func(*EMHUN), check func(*testing.T, *EMHUN))
+}
+
+var oracleModes = []oracleMode{
+	{"plain", plainOracle},
+	{"top-k", topKOracle},
+	{"closed", closedOracle},
+	{"maximal", maximalOracle},
+	{"constraints", constraintsOracle},
+	{"top-k/constraints", topKConstraintsOracle},
+	{"min utilities", minUtilitiesOracle},
+	{"closed/min utilities", closedMinUtilitiesOracle},
+}
+
+// TestModesMatchBruteForceRandom runs every oracle mode on 200 random
+// databases mixing positive, hybrid and negative items.
+func TestModesMatchBruteForceRandom(t *testing.T) {
+	for seed, mode := range oracleModes {
+		t.Run(mode.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(int64(seed + 1)))
+			for i := range 200 {
+				transactions := randomTransactions(r)
+				minU := float64(1 + r.Intn(30))
+				configure, check := mode.setup(t, r, transactions, minU)
+				runAllConfigs(t, fmt.Sprintf("db%d", i), transactions, minU, configure, check)
+			}
+		})
+	}
+}
+
+// bruteForce is BruteForce failing the test on error.
+func bruteForce(t *testing.T, transactions []*models.Transaction, minU float64) []*models.HighUtilityItemset {
+	t.Helper()
+	huis, err := BruteForce(transactions, minU)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return huis
+}
+
+// plainOracle expects every HUI at minU.
+func plainOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	want := bruteForce(t, transactions, minU)
+	return nil, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+	}
+}
+
+// topKOracle expects the utilities of the k best itemsets with a positive
+// utility; which itemset wins a tie is not checked.
+func topKOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	return topKOf(t, r, transactions, Constraints{})
+}
+
+// topKOf is topKOracle among the itemsets that c allows.
+func topKOf(t *testing.T, r *rand.Rand, transactions []*models.Transaction, c Constraints) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	k := 1 + r.Intn(10)
+	var want []float64
+	for _, hui := range constrained(transactions, bruteForce(t, transactions, math.SmallestNonzeroFloat64), c) {
+		want = append(want, hui.Utility)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(want)))
+	want = want[:min(k, len(want))]
+
+	configure := func(e *EMHUN) {
+		e.SearchAlgorithms.K = k
+		e.Constraints = c
+	}
+	return configure, func(t *testing.T, e *EMHUN) {
+		var got []float64
+		for _, hui := range e.SearchAlgorithms.HighUtilityItemsets {
+			got = append(got, hui.Utility)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("top-%d utilities %v with %+v, want %v", k, got, c, want)
+		}
+	}
+}
+
+// closureOf returns the items common to every transaction containing itemset.
+func closureOf(transactions []*models.Transaction, itemset []int) []int {
+	var closure []int
+	first := true
+	for _, t := range transactions {
+		items := slices.Compact(slices.Sorted(slices.Values(t.Items)))
+		if !isSubset(itemset, items) {
+			continue
+		}
+		if first {
+			closure, first = items, false
+		} else {
+			closure = slices.DeleteFunc(closure, func(item int) bool { return !slices.Contains(items, item) })
+		}
+	}
+	return closure
+}
+
+// closedOracle expects the HUIs that are their own closure, and that
+// RecoverHUIs rebuilds the HUIs whose closure is a HUI, at minU and above.
+func closedOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	huis := bruteForce(t, transactions, minU)
+	utilities := make(map[string]float64)
+	for _, hui := range huis {
+		utilities[fmt.Sprint(hui.Itemset)] = hui.Utility
+	}
+	var closed []*models.HighUtilityItemset
+	recoverable := func(threshold float64) []*models.HighUtilityItemset {
+		var result []*models.HighUtilityItemset
+		for _, hui := range huis {
+			if _, ok := utilities[fmt.Sprint(closureOf(transactions, hui.Itemset))]; ok && hui.Utility >= threshold {
+				result = append(result, hui)
+			}
+		}
+		return result
+	}
+	for _, hui := range huis {
+		if slices.Equal(closureOf(transactions, hui.Itemset), hui.Itemset) {
+			closed = append(closed, hui)
+		}
+	}
+	want, higher := recoverable(minU), recoverable(minU+5)
+
+	configure := func(e *EMHUN) { e.Closed = true }
+	return configure, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, closed)
+		for _, c := range e.ClosedItemsets {
+			for _, g := range c.Generators {
+				if got := closureOf(transactions, g); !slices.Equal(got, c.Itemset) {
+					t.Errorf("generator %v of %v has closure %v", g, c.Itemset, got)
+				}
+			}
+		}
+		compareHUIs(t, RecoverHUIs(e.ClosedItemsets, minU), want)
+		// Ngưỡng cao hơn cũng khôi phục được từ cùng tập đóng
+		compareHUIs(t, RecoverHUIs(e.ClosedItemsets, minU+5), higher)
+	}
+}
+
+// TestClosedBelowThreshold mines table3.txt at 30, where the closure of the
+// HUI {2, 4, 5} is {2, 4, 5, 6} with a utility of only 25. Only the closures
+// that are HUIs are reported.
+func TestClosedBelowThreshold(t *testing.T) {
+	data, err := dataset.ReadFile("../data/table3.txt", dataset.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runAllConfigs(t, "table3", data.Transactions, 30, func(e *EMHUN) { e.Closed = true }, func(t *testing.T, e *EMHUN) {
+		want := []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{3, 4, 5}, 37),
+			models.NewHighUtilityItemset([]int{4, 5}, 37),
+		}
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+		if len(e.ClosedItemsets) != 2 || e.ClosedItemsets[0].Support != 3 || e.ClosedItemsets[1].Support != 4 {
+			t.Errorf("closed itemsets %v, want supports 3 and 4", e.ClosedItemsets)
+		}
+		// {3, 4} và {3, 4, 5} có cùng bao đóng
+		recovered := RecoverHUIs(e.ClosedItemsets, 30)
+		compareHUIs(t, recovered, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{3, 4}, 31),
+			models.NewHighUtilityItemset([]int{3, 4, 5}, 37),
+			models.NewHighUtilityItemset([]int{4, 5}, 37),
+		})
+	})
+}
+
+// maximalOracle expects the HUIs without a HUI superset.
+func maximalOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	all := bruteForce(t, transactions, minU)
+	var want []*models.HighUtilityItemset
+	for _, hui := range all {
+		itemset := slices.Sorted(slices.Values(hui.Itemset))
+		if !slices.ContainsFunc(all, func(other *models.HighUtilityItemset) bool {
+			return len(other.Itemset) > len(itemset) && isSubset(itemset, slices.Sorted(slices.Values(other.Itemset)))
+		}) {
+			want = append(want, hui)
+		}
+	}
+	configure := func(e *EMHUN) { e.SearchAlgorithms.Maximal = true }
+	return configure, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+	}
+}
+
+// TestMaximalSkipsSubtrees mines a database where {1, 2, 3} is found first
+// and covers every node that comes after it below {1}, {2} and {3}.
+func TestMaximalSkipsSubtrees(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2, 3}, []float64{5, 5, 5}, 15),
+		models.NewTransaction([]int{4}, []float64{20}, 20),
+	}
+	runAllConfigs(t, "maximal", transactions, 10, func(e *EMHUN) { e.SearchAlgorithms.Maximal = true }, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{1, 2, 3}, 15),
+			models.NewHighUtilityItemset([]int{4}, 20),
+		})
+		// Các worker song song không thấy tập tối đại của nhau, nên chỉ
+		// kiểm tra việc cắt cây con khi chạy tuần tự
+		if e.Workers == 1 && e.Stats.PrunedByMaximal == 0 {
+			t.Error("no subtree was skipped")
+		}
+	})
+}
+
+// TestMaximalMaxHUIs checks that MaxHUIs applies to the maximal itemsets.
+// Table 3 has 4 HUIs at minU 30, 2 of them maximal.
+func TestMaximalMaxHUIs(t *testing.T) {
+	data, err := dataset.ReadFile("../data/table3.txt", dataset.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		maxHUIs  int
+		complete bool
+	}{{2, true}, {1, false}} {
+		runAllConfigs(t, fmt.Sprintf("max=%d", tt.maxHUIs), data.Transactions, 30, func(e *EMHUN) {
+			e.SearchAlgorithms.Maximal = true
+			e.Limits.MaxHUIs = tt.maxHUIs
+		}, func(t *testing.T, e *EMHUN) {
+			if got := len(e.SearchAlgorithms.HighUtilityItemsets); got != tt.maxHUIs {
+				t.Errorf("%d itemsets, want %d", got, tt.maxHUIs)
+			}
+			if e.Status.Complete != tt.complete || !tt.complete && e.Status.Reason != StopMaxHUIs {
+				t.Errorf("status %+v, want complete = %t", e.Status, tt.complete)
+			}
+		})
+	}
+}
+
+// randomConstraints picks length bounds, an η cap and item constraints over
+// the items of randomTransactions, each unset half of the time.
+func randomConstraints(r *rand.Rand) Constraints {
+	var c Constraints
+	if r.Intn(2) == 0 {
+		c.MinLength = 1 + r.Intn(3)
+	}
+	if r.Intn(2) == 0 {
+		c.MaxLength = max(1, c.MinLength) + r.Intn(3)
+	}
+	if r.Intn(2) == 0 {
+		c.MaxNegativeItems = NoNegativeItems + r.Intn(3)
+	}
+	items := r.Perm(10)
+	for i := range items {
+		items[i]++
+	}
+	if r.Intn(2) == 0 {
+		c.MustContain, items = items[:1+r.Intn(2)], items[2:]
+	}
+	if r.Intn(2) == 0 {
+		c.MustNotContain, items = items[:1+r.Intn(3)], items[3:]
+	}
+	if r.Intn(2) == 0 {
+		c.AnyOf = items[:1+r.Intn(3)]
+	}
+	return c
+}
+
+// constrained keeps the itemsets of huis that c allows.
+func constrained(transactions []*models.Transaction, huis []*models.HighUtilityItemset, c Constraints) []*models.HighUtilityItemset {
+	e := NewEMHUN(transactions, 0)
+	e.ClassifyItems()
+	var kept []*models.HighUtilityItemset
+	for _, hui := range huis {
+		negatives := 0
+		for _, item := range hui.Itemset {
+			if e.Eta[item] {
+				negatives++
+			}
+		}
+		if c.allows(len(hui.Itemset), negatives) && c.allowsItems(hui.Itemset) {
+			kept = append(kept, hui)
+		}
+	}
+	return kept
+}
+
+// constraintsOracle expects the HUIs that random constraints allow, in a
+// complete run: reaching MaxLength does not truncate the search.
+func constraintsOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	c := randomConstraints(r)
+	want := constrained(transactions, bruteForce(t, transactions, minU), c)
+	configure := func(e *EMHUN) { e.Constraints = c }
+	return configure, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+		if !e.Status.Complete {
+			t.Errorf("run incomplete: %s", e.Status.Reason)
+		}
+		if t.Failed() {
+			t.Logf("constraints = %+v", c)
+		}
+	}
+}
+
+func topKConstraintsOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	return topKOf(t, r, transactions, randomConstraints(r))
+}
+
+// TestMaxNegativeItemsHybrid checks that only η items count against
+// MaxNegativeItems: item 2 is negative in the first transaction but hybrid
+// over the dataset, item 3 is η.
+func TestMaxNegativeItemsHybrid(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2, 3}, []float64{10, -2, -1}, 7),
+		models.NewTransaction([]int{2}, []float64{5}, 5),
+	}
+	tests := []struct {
+		maxNegative int
+		want        []*models.HighUtilityItemset
+	}{
+		{NoNegativeItems, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{1}, 10),
+			models.NewHighUtilityItemset([]int{1, 2}, 8),
+		}},
+		{1, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{1}, 10),
+			models.NewHighUtilityItemset([]int{1, 2}, 8),
+			models.NewHighUtilityItemset([]int{1, 2, 3}, 7),
+			models.NewHighUtilityItemset([]int{1, 3}, 9),
+		}},
+	}
+	for _, tt := range tests {
+		runAllConfigs(t, fmt.Sprintf("max=%d", tt.maxNegative), transactions, 5, func(e *EMHUN) {
+			e.Constraints.MaxNegativeItems = tt.maxNegative
+		}, func(t *testing.T, e *EMHUN) {
+			compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, tt.want)
+		})
+	}
+}
+
+func TestItemConstraints(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2, 3}, []float64{10, -2, -1}, 7),
+		models.NewTransaction([]int{1, 2}, []float64{1, 5}, 6),
+	}
+	runAllConfigs(t, "must-contain", transactions, 5, func(e *EMHUN) {
+		e.Constraints.MustContain = []int{1}
+	}, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{1}, 11),
+			models.NewHighUtilityItemset([]int{1, 2}, 14),
+			models.NewHighUtilityItemset([]int{1, 2, 3}, 7),
+			models.NewHighUtilityItemset([]int{1, 3}, 9),
+		})
+		// Cây con của {2} không chứa 1 nên bị cắt
+		if e.Stats.PrunedByConstraints == 0 {
+			t.Error("no subtree was pruned")
+		}
+	})
+	// Item 4 không có trong dữ liệu nên không tập nào thỏa ràng buộc
+	runAllConfigs(t, "unknown", transactions, 5, func(e *EMHUN) {
+		e.Constraints.MustContain = []int{4}
+	}, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, nil)
+	})
+	runAllConfigs(t, "must-not-contain", transactions, 5, func(e *EMHUN) {
+		e.Constraints.MustNotContain = []int{3}
+	}, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{1}, 11),
+			models.NewHighUtilityItemset([]int{1, 2}, 14),
+		})
+	})
+}
+
+// randomMinUtilities gives about half of the items of randomTransactions
+// their own threshold, and picks an aggregate.
+func randomMinUtilities(r *rand.Rand) (map[int]float64, Aggregate) {
+	aggregates := []Aggregate{AggregateMin, AggregateMax, AggregateAverage}
+	minUtilities := make(map[int]float64)
+	for item := 1; item <= 10; item++ {
+		if r.Intn(2) == 0 {
+			minUtilities[item] = float64(1 + r.Intn(30))
+		}
+	}
+	return minUtilities, aggregates[r.Intn(len(aggregates))]
+}
+
+// perItemHUIs returns the itemsets whose utility reaches the aggregate of the
+// thresholds of their items, the items missing from minUtilities having minU.
+func perItemHUIs(t *testing.T, transactions []*models.Transaction, minU float64, minUtilities map[int]float64, aggregate Aggregate) []*models.HighUtilityItemset {
+	lowest := minU
+	for _, threshold := range minUtilities {
+		lowest = min(lowest, threshold)
+	}
+	var huis []*models.HighUtilityItemset
+	for _, hui := range bruteForce(t, transactions, lowest) {
+		var thresholds itemThresholds
+		for _, item := range hui.Itemset {
+			threshold, ok := minUtilities[item]
+			if !ok {
+				threshold = minU
+			}
+			thresholds = thresholds.add(threshold)
+		}
+		if hui.Utility >= thresholds.value(aggregate) {
+			huis = append(huis, hui)
+		}
+	}
+	return huis
+}
+
+// minUtilitiesOracle expects the HUIs under random per-item thresholds.
+func minUtilitiesOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	minUtilities, aggregate := randomMinUtilities(r)
+	want := perItemHUIs(t, transactions, minU, minUtilities, aggregate)
+	configure := func(e *EMHUN) {
+		e.MinUtilities = minUtilities
+		e.Aggregate = aggregate
+	}
+	return configure, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+		if t.Failed() {
+			t.Logf("%s of %v", aggregate, minUtilities)
+		}
+	}
+}
+
+// closedMinUtilitiesOracle expects the HUIs under random per-item thresholds
+// that are their own closure: a closure is compared with its own threshold.
+func closedMinUtilitiesOracle(t *testing.T, r *rand.Rand, transactions []*models.Transaction, minU float64) (func(*EMHUN), func(*testing.T, *EMHUN)) {
+	minUtilities, aggregate := randomMinUtilities(r)
+	var want []*models.HighUtilityItemset
+	for _, hui := range perItemHUIs(t, transactions, minU, minUtilities, aggregate) {
+		if slices.Equal(closureOf(transactions, hui.Itemset), hui.Itemset) {
+			want = append(want, hui)
+		}
+	}
+	configure := func(e *EMHUN) {
+		e.Closed = true
+		e.MinUtilities = minUtilities
+		e.Aggregate = aggregate
+	}
+	return configure, func(t *testing.T, e *EMHUN) {
+		compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, want)
+		if t.Failed() {
+			t.Logf("%s of %v", aggregate, minUtilities)
+		}
+	}
+}
+
+// The η extensions kept at a node are the candidates of all its descendants,
+// so their bound must allow for the lower thresholds of the η items between.
+// Here {7 5 4 3 1 2} has threshold 6 through item 1 but was pruned when item 2
+// was compared with the thresholds of {7 5 4 3 2}.
+func TestMinUtilitiesNegativeDescendants(t *testing.T) {
+	rows := []struct {
+		items     []int
+		utilities []float64
+	}{
+		{[]int{1, 2, 3, 5}, []float64{-10, -4, -2, 4}},
+		{[]int{1, 5, 6}, []float64{-8, 3, 10}},
+		{[]int{6, 7}, []float64{2, 3}},
+		{[]int{2, 3, 7}, []float64{-8, -6, 6}},
+		{[]int{1, 2, 4, 7}, []float64{-6, -9, -3, 2}},
+		{[]int{1, 2, 3, 4, 5, 7}, []float64{-2, -4, -7, 8, 8, 4}},
+		{[]int{2, 3, 6}, []float64{-10, -10, 3}},
+		{[]int{1, 2, 4}, []float64{-1, -2, 6}},
+		{[]int{1, 2, 6}, []float64{-3, -2, 8}},
+		{[]int{2, 4, 5}, []float64{-9, 4, 3}},
+	}
+	var transactions []*models.Transaction
+	for _, row := range rows {
+		transactions = append(transactions, models.NewTransaction(row.items, row.utilities, calculateTransactionUtility(row.utilities)))
+	}
+	runAllConfigs(t, "table", transactions, 22, func(e *EMHUN) {
+		e.MinUtilities = map[int]float64{1: 6, 2: 21, 3: 24, 4: 22}
+	}, func(t *testing.T, e *EMHUN) {
+		if _, ok := canonical(e.SearchAlgorithms.HighUtilityItemsets)["[1 2 3 4 5 7]"]; !ok {
+			t.Error("HUI [1 2 3 4 5 7] not found")
+		}
+	})
+}
+
+// TestClosedMinUtilities checks that in closed mode a closure is compared
+// with its own threshold: the closure of {1} is {1, 2}, whose threshold is
+// raised by item 2 with AggregateMax.
+func TestClosedMinUtilities(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2}, []float64{10, 1}, 11),
+	}
+	tests := []struct {
+		aggregate Aggregate
+		want      []*models.HighUtilityItemset
+	}{
+		{AggregateMin, []*models.HighUtilityItemset{models.NewHighUtilityItemset([]int{1, 2}, 11)}},
+		{AggregateMax, nil},
+	}
+	for _, tt := range tests {
+		runAllConfigs(t, string(tt.aggregate), transactions, 5, func(e *EMHUN) {
+			e.Closed = true
+			e.MinUtilities = map[int]float64{2: 20}
+			e.Aggregate = tt.aggregate
+		}, func(t *testing.T, e *EMHUN) {
+			compareHUIs(t, e.SearchAlgorithms.HighUtilityItemsets, tt.want)
+		})
+	}
+}
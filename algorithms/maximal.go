@@ -0,0 +1,175 @@
+package algorithms
+
+import (
+	"emhun/models"
+	"slices"
+)
+
+// In maximal mode (SearchAlgorithms.Maximal) only HUIs without a HUI superset
+// are reported. Found HUIs go into a maximalSet, which drops the itemsets
+// they contain, instead of HighUtilityItemsets. Before exploring a node the
+// search checks whether the node and every item its subtree can still add fit
+// in a maximal HUI already found; if so, nothing below can be maximal and the
+// subtree is skipped.
+
+// maximalSet holds the itemsets, as ascending dense ids, that are not
+// contained in another itemset of the set.
+type maximalSet struct {
+	itemsets  [][]int // nil once removed
+	utilities []float64
+	byItem    map[int][]int // indices of the itemsets containing each item
+	live      int
+}
+
+func newMaximalSet() *maximalSet {
+	return &maximalSet{byItem: make(map[int][]int)}
+}
+
+// containsSuperset reports whether some itemset of the set contains itemset,
+// which must be in ascending order.
+func (m *maximalSet) containsSuperset(itemset []int) bool {
+	if len(itemset) == 0 {
+		return m.live > 0
+	}
+	// Chỉ cần duyệt danh sách ngắn nhất trong các item của itemset
+	candidates := m.byItem[itemset[0]]
+	for _, item := range itemset[1:] {
+		if list := m.byItem[item]; len(list) < len(candidates) {
+			candidates = list
+		}
+	}
+	for _, i := range candidates {
+		if m.itemsets[i] != nil && isSubset(itemset, m.itemsets[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// add inserts itemset unless the set already contains a superset of it, and
+// removes the itemsets it contains. The result does not depend on the order
+// of the insertions.
+func (m *maximalSet) add(itemset []int, utility float64) {
+	if m.containsSuperset(itemset) {
+		return
+	}
+	for _, item := range itemset {
+		for _, i := range m.byItem[item] {
+			if m.itemsets[i] != nil && isSubset(m.itemsets[i], itemset) {
+				m.itemsets[i] = nil
+				m.live--
+			}
+		}
+	}
+	for _, item := range itemset {
+		m.byItem[item] = append(m.byItem[item], len(m.itemsets))
+	}
+	m.itemsets = append(m.itemsets, itemset)
+	m.utilities = append(m.utilities, utility)
+	m.live++
+
+	if removed := len(m.itemsets) - m.live; removed > 1024 && removed > m.live {
+		m.compact()
+	}
+}
+
+// compact forgets the removed itemsets.
+func (m *maximalSet) compact() {
+	itemsets, utilities := m.itemsets, m.utilities
+	m.itemsets, m.utilities = nil, nil
+	m.byItem = make(map[int][]int)
+	m.live = 0
+	for i, itemset := range itemsets {
+		if itemset != nil {
+			for _, item := range itemset {
+				m.byItem[item] = append(m.byItem[item], len(m.itemsets))
+			}
+			m.itemsets = append(m.itemsets, itemset)
+			m.utilities = append(m.utilities, utilities[i])
+			m.live++
+		}
+	}
+}
+
+// merge adds the itemsets of other, e.g. those found by another worker.
+func (m *maximalSet) merge(other *maximalSet) {
+	for i, itemset := range other.itemsets {
+		if itemset != nil {
+			m.add(itemset, other.utilities[i])
+		}
+	}
+}
+
+// newMaximalSet returns an empty set if s runs in maximal mode, nil
+// otherwise.
+func (s *SearchAlgorithms) newMaximalSet() *maximalSet {
+	if !s.Maximal || s.K > 0 {
+		return nil
+	}
+	return newMaximalSet()
+}
+
+// addMaximal records a HUI found in maximal mode.
+func (s *SearchAlgorithms) addMaximal(itemset []int, utility float64) {
+	s.maximal.add(slices.Sorted(slices.Values(itemset)), utility)
+}
+
+// subtreeSubsumed reports whether beta and everything below it are contained
+// in a maximal HUI already found. Items below beta come after its last item
+// in processing order and occur in the transactions containing beta, given
+// either as transactions or, for the utility-list engine, as the tids of list.
+func (s *SearchAlgorithms) subtreeSubsumed(beta []int, transactions []*models.Transaction, list *models.UtilityList) bool {
+	if s.maximal == nil || s.maximal.live == 0 {
+		return false
+	}
+	last := slices.Max(beta)
+	seen := make(map[int]bool)
+	union := slices.Clone(beta)
+	collect := func(items []int) {
+		for _, item := range items {
+			if item > last && !seen[item] {
+				seen[item] = true
+				union = append(union, item)
+			}
+		}
+	}
+	if list != nil {
+		for _, entry := range list.Entries {
+			collect(s.transactions[entry.Tid].Items)
+		}
+	} else {
+		for _, transaction := range transactions {
+			collect(transaction.Items)
+		}
+	}
+	slices.Sort(union)
+	if s.maximal.containsSuperset(union) {
+		s.counters.prunedMaximal++
+		return true
+	}
+	return false
+}
+
+// maximalResults returns the maximal HUIs with dataset ids, sorted by their
+// items in processing order.
+func (s *SearchAlgorithms) maximalResults() []*models.HighUtilityItemset {
+	var itemsets [][]int
+	var utilities []float64
+	for i, itemset := range s.maximal.itemsets {
+		if itemset != nil {
+			itemsets = append(itemsets, itemset)
+			utilities = append(utilities, s.maximal.utilities[i])
+		}
+	}
+	order := make([]int, len(itemsets))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return slices.Compare(itemsets[a], itemsets[b]) })
+
+	results := make([]*models.HighUtilityItemset, len(order))
+	for i, j := range order {
+		results[i] = models.NewHighUtilityItemset(s.itemNames(itemsets[j]), utilities[j])
+	}
+	return results
+}
@@ -0,0 +1,174 @@
+package algorithms
+
+import (
+	"fmt"
+	"math"
+)
+
+// With EMHUN.MinUtilities every item has its own minimum utility, the items
+// missing from the table keeping EMHUN.MinUtility. The threshold of an itemset
+// is the Aggregate of the thresholds of its items. It is no longer the same
+// for an itemset and its extensions, so each RTWU, RSU or RLU pruning
+// compares the bound with the lowest threshold any itemset it covers can
+// have, see itemThresholds.bound.
+
+// Aggregate derives the threshold of an itemset from those of its items.
+type Aggregate string
+
+const (
+	AggregateMin     Aggregate = "min"
+	AggregateMax     Aggregate = "max"
+	AggregateAverage Aggregate = "avg"
+)
+
+// ParseAggregate returns the Aggregate named s.
+func ParseAggregate(s string) (Aggregate, error) {
+	switch a := Aggregate(s); a {
+	case AggregateMin, AggregateMax, AggregateAverage:
+		return a, nil
+	}
+	return "", fmt.Errorf("unknown aggregate %q, want min, max or avg", s)
+}
+
+// itemThresholds summarises the item thresholds of an itemset.
+type itemThresholds struct {
+	min, max, sum float64
+	n             int
+}
+
+func (t itemThresholds) add(threshold float64) itemThresholds {
+	if t.n == 0 {
+		t.min, t.max = threshold, threshold
+	} else {
+		t.min, t.max = min(t.min, threshold), max(t.max, threshold)
+	}
+	t.sum += threshold
+	t.n++
+	return t
+}
+
+// value is the threshold of the itemset itself.
+func (t itemThresholds) value(aggregate Aggregate) float64 {
+	switch aggregate {
+	case AggregateMax:
+		return t.max
+	case AggregateAverage:
+		return t.sum / float64(t.n)
+	}
+	return t.min
+}
+
+// bound is a lower bound on the threshold of every non-empty itemset Z with
+// X ⊆ Z ⊆ X ∪ E, where t summarises X and lowest is the smallest threshold
+// of the items of E (+Inf if E is empty). Adding items can only lower the
+// minimum and only raise the maximum, and keeps the average above the
+// smaller of the current average and the added thresholds.
+func (t itemThresholds) bound(aggregate Aggregate, lowest float64) float64 {
+	if t.n == 0 {
+		return lowest
+	}
+	switch aggregate {
+	case AggregateMax:
+		return t.max
+	case AggregateAverage:
+		return min(t.sum/float64(t.n), lowest)
+	}
+	return min(t.min, lowest)
+}
+
+// perItem reports whether the run uses per-item thresholds.
+func (e *EMHUN) perItem() bool {
+	return e.MinUtilities != nil && e.SearchAlgorithms.K == 0
+}
+
+// minUtilityOf returns the threshold of a dense item id.
+func (e *EMHUN) minUtilityOf(item int) float64 {
+	if threshold, ok := e.MinUtilities[e.SearchAlgorithms.ItemNames[item]]; ok {
+		return threshold
+	}
+	return e.MinUtility
+}
+
+// secondaryThreshold returns, for getSecondaryItems, the lowest threshold of
+// an itemset containing item: any other item of the database, the lowest
+// threshold of which is lowest, may join it. In closed mode the item must stay
+// in the transactions if it can be in the closure of a HUI, that is if its
+// RTWU reaches lowest.
+func (e *EMHUN) secondaryThreshold(item int, lowest, minU float64) float64 {
+	if !e.perItem() {
+		return minU
+	}
+	if e.SearchAlgorithms.closed != nil {
+		return lowest
+	}
+	return itemThresholds{}.add(e.minUtilityOf(item)).bound(e.Aggregate, lowest)
+}
+
+// lowestMinUtility returns the smallest threshold among the items of sets.
+func (e *EMHUN) lowestMinUtility(sets ...map[int]bool) float64 {
+	lowest := math.Inf(1)
+	for _, set := range sets {
+		for item := range set {
+			lowest = min(lowest, e.minUtilityOf(item))
+		}
+	}
+	return lowest
+}
+
+// setItemMinUtilities passes the thresholds to the search with the ids set by
+// renameItemsInProcessingOrder. suffixMin[i] is the lowest threshold of the
+// items from i on, which are those that can follow an item before i.
+func (e *EMHUN) setItemMinUtilities() {
+	s := e.SearchAlgorithms
+	s.minUtilities, s.suffixMin = nil, nil
+	if !e.perItem() {
+		return
+	}
+	s.aggregate = e.Aggregate
+	s.minUtilities = make([]float64, len(e.SearchAlgorithms.ItemNames))
+	s.suffixMin = make([]float64, len(e.SearchAlgorithms.ItemNames)+1)
+	s.suffixMin[len(e.SearchAlgorithms.ItemNames)] = math.Inf(1)
+	for id := len(e.SearchAlgorithms.ItemNames) - 1; id >= 0; id-- {
+		s.minUtilities[id] = e.minUtilityOf(id)
+		s.suffixMin[id] = min(s.minUtilities[id], s.suffixMin[id+1])
+	}
+}
+
+// thresholdsOf summarises the item thresholds of itemset; it is only needed
+// with per-item thresholds.
+func (s *SearchAlgorithms) thresholdsOf(itemset []int) itemThresholds {
+	var t itemThresholds
+	if s.minUtilities == nil {
+		return t
+	}
+	for _, item := range itemset {
+		t = t.add(s.minUtilities[item])
+	}
+	return t
+}
+
+// huiThreshold is the threshold of the itemset summarised by t.
+func (s *SearchAlgorithms) huiThreshold(t itemThresholds, minU float64) float64 {
+	if s.minUtilities == nil {
+		return s.threshold(minU)
+	}
+	return t.value(s.aggregate)
+}
+
+// subtreeThreshold is the lowest threshold of an itemset made of the itemset
+// summarised by t and items from from on.
+func (s *SearchAlgorithms) subtreeThreshold(t itemThresholds, from int, minU float64) float64 {
+	if s.minUtilities == nil {
+		return s.threshold(minU)
+	}
+	return t.bound(s.aggregate, s.suffixMin[min(from, len(s.minUtilities))])
+}
+
+// extensionThreshold is the lowest threshold of an itemset made of the
+// itemset summarised by t, item and items from from on.
+func (s *SearchAlgorithms) extensionThreshold(t itemThresholds, item, from int, minU float64) float64 {
+	if s.minUtilities == nil {
+		return s.threshold(minU)
+	}
+	return t.add(s.minUtilities[item]).bound(s.aggregate, s.suffixMin[min(from, len(s.minUtilities))])
+}
@@ -0,0 +1,236 @@
+package algorithms
+
+import (
+	"container/heap"
+	"emhun/models"
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// searchTask is one branch of the search: extending prefix with item, then
+// exploring everything below it. key is the path of branch indices from the
+// root and orders the results like the sequential search would.
+type searchTask struct {
+	key          []int
+	eta          []int
+	prefix       map[int]bool
+	prefixItems  []int
+	transactions []*models.Transaction
+	list         *models.UtilityList
+	item         int
+	secondary    []int
+	minU         float64
+}
+
+type taskResult struct {
+	key  []int
+	huis []*models.HighUtilityItemset
+}
+
+// taskQueue is a LIFO queue shared by the workers. pending counts queued and
+// running tasks, so pop only reports completion once no task can spawn more.
+type taskQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []*searchTask
+	pending int
+}
+
+func newTaskQueue() *taskQueue {
+	q := &taskQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *taskQueue) push(task *searchTask) {
+	q.mu.Lock()
+	q.tasks = append(q.tasks, task)
+	q.pending++
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+func (q *taskQueue) pop() *searchTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.tasks) == 0 && q.pending > 0 {
+		q.cond.Wait()
+	}
+	if len(q.tasks) == 0 {
+		return nil
+	}
+	task := q.tasks[len(q.tasks)-1]
+	q.tasks = q.tasks[:len(q.tasks)-1]
+	return task
+}
+
+func (q *taskQueue) done() {
+	q.mu.Lock()
+	q.pending--
+	finished := q.pending == 0
+	q.mu.Unlock()
+	if finished {
+		q.cond.Broadcast()
+	}
+}
+
+// sharedThreshold publishes the best top-k threshold found by any worker.
+// Every worker's k-th best utility is a valid lower bound for the global one.
+type sharedThreshold struct {
+	bits atomic.Uint64
+}
+
+func (t *sharedThreshold) get() float64 {
+	return math.Float64frombits(t.bits.Load())
+}
+
+func (t *sharedThreshold) raise(value float64) {
+	for {
+		old := t.bits.Load()
+		if value <= math.Float64frombits(old) {
+			return
+		}
+		if t.bits.CompareAndSwap(old, math.Float64bits(value)) {
+			return
+		}
+	}
+}
+
+// newWorker returns a copy of s with its own scratch state (Beta, ItemList,
+// filtered lists, RSU/RLU arrays and results) and the read-only parts shared.
+func (s *SearchAlgorithms) newWorker(queue *taskQueue, splitDepth int, shared *sharedThreshold) *SearchAlgorithms {
+	w := &SearchAlgorithms{
+		UtilityArray:        models.NewUtilityArray(s.UtilityArray.Size()),
+		Beta:                make(map[int]bool),
+		HighUtilityItemsets: []*models.HighUtilityItemset{},
+		K:                   s.K,
+		Maximal:             s.Maximal,
+		maximal:             s.newMaximalSet(),
+		ItemLists:           s.ItemLists,
+		constraints:         s.constraints,
+		firstEta:            s.firstEta,
+		negative:            s.negative,
+		mixedEta:            s.mixedEta,
+		required:            s.required,
+		minUtilities:        s.minUtilities,
+		suffixMin:           s.suffixMin,
+		aggregate:           s.aggregate,
+		anyOf:               s.anyOf,
+		ItemNames:           s.ItemNames,
+		transactions:        s.transactions,
+		queue:               queue,
+		splitDepth:          splitDepth,
+		sharedThreshold:     shared,
+		control:             s.control,
+		Logger:              s.Logger,
+	}
+	if s.closed != nil {
+		w.closed = newClosedSet(s.closed.minU)
+	}
+	return w
+}
+
+// shouldSplit reports whether the children of a node with depth items are
+// queued as separate tasks instead of being explored by the current worker.
+func (s *SearchAlgorithms) shouldSplit(depth int) bool {
+	return s.queue != nil && depth < s.splitDepth
+}
+
+// spawn queues one task per primary item below the current node.
+func (s *SearchAlgorithms) spawn(template searchTask, primary []int) {
+	for i, item := range primary {
+		task := template
+		task.key = append(slices.Clone(s.taskKey), i)
+		task.item = item
+		s.queue.push(&task)
+	}
+}
+
+func (s *SearchAlgorithms) runTask(engine Engine, task *searchTask) {
+	s.taskKey = task.key
+	s.HighUtilityItemsets = []*models.HighUtilityItemset{}
+	switch engine {
+	case EngineUtilityList:
+		s.SearchUtilityList(task.eta, task.prefixItems, task.list, []int{task.item}, task.secondary, task.minU)
+	default:
+		s.Search(task.eta, task.prefix, task.transactions, []int{task.item}, task.secondary, task.minU)
+	}
+}
+
+// runParallel explores the primary items on e.Workers goroutines. Each
+// top-level branch is a task; with SplitDepth > 1, nodes with fewer items than
+// SplitDepth queue their children as tasks too, so idle workers can take over
+// parts of a large subtree. Results are merged in the order of the sequential
+// search.
+func (e *EMHUN) runParallel() {
+	queue := newTaskQueue()
+	shared := &sharedThreshold{}
+	shared.raise(e.MinUtility)
+	splitDepth := max(e.SplitDepth, 1)
+
+	root := e.SearchAlgorithms.newWorker(queue, splitDepth, shared)
+	root.spawn(searchTask{
+		eta:          e.SortedEta,
+		prefix:       make(map[int]bool),
+		transactions: e.Transactions,
+		secondary:    e.SortedSecondary,
+		minU:         e.MinUtility,
+	}, e.PrimaryItems)
+
+	workers := make([]*SearchAlgorithms, e.Workers)
+	results := make([][]taskResult, e.Workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		workers[i] = e.SearchAlgorithms.newWorker(queue, splitDepth, shared)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := workers[i]
+			for task := queue.pop(); task != nil; task = queue.pop() {
+				w.runTask(e.Engine, task)
+				results[i] = append(results[i], taskResult{key: task.key, huis: w.HighUtilityItemsets})
+				queue.done()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	var merged []taskResult
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return slices.Compare(merged[i].key, merged[j].key) < 0
+	})
+
+	s := e.SearchAlgorithms
+	for _, w := range workers {
+		s.counters.add(w.counters)
+		if s.closed != nil {
+			s.closed.merge(w.closed)
+		}
+		// Mỗi worker chỉ loại các tập con trong phần nó đã duyệt; gộp lại để
+		// lọc lần cuối trên toàn bộ kết quả
+		if s.maximal != nil {
+			s.maximal.merge(w.maximal)
+		}
+	}
+	for _, r := range merged {
+		s.HighUtilityItemsets = append(s.HighUtilityItemsets, r.huis...)
+	}
+	if s.K > 0 {
+		var candidates huiHeap
+		for _, w := range workers {
+			candidates = append(candidates, w.topK...)
+		}
+		sortByUtility(candidates)
+		if len(candidates) > s.K {
+			candidates = candidates[:s.K]
+		}
+		s.topK = candidates
+		heap.Init(&s.topK)
+	}
+}
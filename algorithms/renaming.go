@@ -0,0 +1,160 @@
+package algorithms
+
+import (
+	"emhun/models"
+	"slices"
+)
+
+// encodeItems replaces e.Transactions by copies where the dataset item ids
+// are renamed to dense ids 0..n-1, in order of first appearance, so that
+// UtilityArray can be indexed directly. The later steps work in place on
+// these copies, leaving the caller's transactions unchanged. The original
+// ids are kept in SearchAlgorithms.ItemNames.
+func (e *EMHUN) encodeItems() {
+	ids := make(map[int]int)
+	var names []int
+	encoded := make([]*models.Transaction, len(e.Transactions))
+	for t, transaction := range e.Transactions {
+		items := make([]int, len(transaction.Items))
+		for i, item := range transaction.Items {
+			id, ok := ids[item]
+			if !ok {
+				id = len(names)
+				ids[item] = id
+				names = append(names, item)
+			}
+			items[i] = id
+		}
+		encoded[t] = models.NewTransaction(items, slices.Clone(transaction.Utilities), transaction.TransactionUtility)
+	}
+	e.Transactions = encoded
+	e.SearchAlgorithms.ItemNames = names
+	e.UtilityArray.Reset(len(names))
+}
+
+// renameItemsInProcessingOrder renumbers the items kept by FilterTransactions
+// so that SortedSecondary followed by SortedEta becomes 0..n-1. After this,
+// comparing two ids compares their position in the processing order (ρ, δ, η,
+// each by ascending RTWU).
+func (e *EMHUN) renameItemsInProcessingOrder() {
+	e.saveDatasetItems()
+
+	order := make([]int, 0, len(e.SortedSecondary)+len(e.SortedEta))
+	order = append(order, e.SortedSecondary...)
+	order = append(order, e.SortedEta...)
+
+	newIDs := make([]int, e.UtilityArray.Size())
+	for i := range newIDs {
+		newIDs[i] = -1
+	}
+	names := make([]int, len(order))
+	rtwus := make([]float64, len(order))
+	for id, item := range order {
+		newIDs[item] = id
+		names[id] = e.SearchAlgorithms.ItemNames[item]
+		rtwus[id] = e.UtilityArray.GetRTWU(item)
+	}
+
+	e.Rho = renameSet(e.Rho, newIDs)
+	e.Delta = renameSet(e.Delta, newIDs)
+	e.Eta = renameSet(e.Eta, newIDs)
+	for i, item := range e.SortedSecondary {
+		e.SortedSecondary[i] = newIDs[item]
+	}
+	for i, item := range e.SortedEta {
+		e.SortedEta[i] = newIDs[item]
+	}
+	for _, transaction := range e.Transactions {
+		for i, item := range transaction.Items {
+			transaction.Items[i] = newIDs[item]
+		}
+	}
+
+	e.SearchAlgorithms.ItemNames = names
+	e.UtilityArray.Reset(len(order))
+	copy(e.UtilityArray.RTWUs, rtwus)
+}
+
+// renameSet keeps the items of set that have a new id, under that id.
+func renameSet(set map[int]bool, newIDs []int) map[int]bool {
+	renamed := make(map[int]bool, len(set))
+	for item := range set {
+		if newIDs[item] >= 0 {
+			renamed[newIDs[item]] = true
+		}
+	}
+	return renamed
+}
+
+// datasetItems holds the classification and the RTWU of every item under
+// its dataset id, including the items FilterTransactions dropped.
+type datasetItems struct {
+	rho, delta, eta map[int]bool
+	rtwu            map[int]float64
+}
+
+// saveDatasetItems records e.Rho, e.Delta, e.Eta and the RTWUs, still in the
+// ids of encodeItems, under the dataset ids for restoreItemIDs.
+func (e *EMHUN) saveDatasetItems() {
+	names := e.SearchAlgorithms.ItemNames
+	named := func(set map[int]bool) map[int]bool {
+		result := make(map[int]bool, len(set))
+		for item := range set {
+			result[names[item]] = true
+		}
+		return result
+	}
+	items := &datasetItems{
+		rho:   named(e.Rho),
+		delta: named(e.Delta),
+		eta:   named(e.Eta),
+		rtwu:  make(map[int]float64, len(names)),
+	}
+	for id, name := range names {
+		items.rtwu[name] = e.UtilityArray.GetRTWU(id)
+	}
+	e.datasetItems = items
+}
+
+// restoreItemIDs puts the dataset ids back into the exported fields once a
+// run is over, so that callers never see the dense ids used for mining: Rho,
+// Delta, Eta and the RTWUs in UtilityArray cover every item again, and
+// SortedSecondary, SortedEta and PrimaryItems keep the processing order. RLU
+// and RSU only hold intermediate values of the search and are cleared.
+func (e *EMHUN) restoreItemIDs() {
+	items := e.datasetItems
+	if items == nil {
+		return
+	}
+	e.datasetItems = nil
+
+	names := e.SearchAlgorithms.ItemNames
+	for _, list := range [][]int{e.SortedSecondary, e.SortedEta, e.PrimaryItems} {
+		for i, item := range list {
+			list[i] = names[item]
+		}
+	}
+	e.Rho, e.Delta, e.Eta = items.rho, items.delta, items.eta
+	e.UtilityArray.Reset(0)
+	for item, rtwu := range items.rtwu {
+		e.UtilityArray.SetRTWU(item, rtwu)
+	}
+}
+
+// itemName maps a dense id back to the dataset item id.
+func (s *SearchAlgorithms) itemName(item int) int {
+	if s.ItemNames == nil {
+		return item
+	}
+	return s.ItemNames[item]
+}
+
+// itemNames returns the dataset ids of items in processing order, so that
+// reported itemsets do not depend on map iteration order.
+func (s *SearchAlgorithms) itemNames(items []int) []int {
+	ordered := slices.Sorted(slices.Values(items))
+	for i, item := range ordered {
+		ordered[i] = s.itemName(item)
+	}
+	return ordered
+}
@@ -0,0 +1,184 @@
+package algorithms
+
+import (
+	"context"
+	"errors"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// The context is polled every ctxCheckInterval visited nodes and the heap
+	// size, for MaxHeapBytes and Stats.PeakHeapBytes, every heapCheckInterval
+	// nodes.
+	ctxCheckInterval  = 256
+	heapCheckInterval = 4096
+
+	heapMetric = "/memory/classes/heap/objects:bytes"
+)
+
+// Limits caps a mining run. Zero values mean no limit.
+type Limits struct {
+	// MaxHUIs stops the search when one more itemset than this is found;
+	// the run is then incomplete and keeps the first MaxHUIs. It is ignored
+	// in top-k mode, where the result size is already bounded. In closed
+	// and maximal mode it caps the closed or maximal itemsets, which are only
+	// known once the search is over.
+	MaxHUIs int
+	// MaxDepth is the largest itemset the search extends to; deeper
+	// extensions are skipped.
+	MaxDepth int
+	// MaxHeapBytes stops the search once the live heap exceeds this size.
+	MaxHeapBytes uint64
+}
+
+// StopReason tells why a run did not explore the whole search space.
+type StopReason string
+
+const (
+	StopCanceled         StopReason = "canceled"
+	StopDeadlineExceeded StopReason = "deadline exceeded"
+	StopMaxHUIs          StopReason = "max HUIs reached"
+	StopMaxDepth         StopReason = "max depth reached"
+	StopMaxHeap          StopReason = "heap limit exceeded"
+	StopInvalidInput     StopReason = "invalid input"
+)
+
+// RunStatus reports whether HighUtilityItemsets is the complete result. When
+// Complete is false the itemsets found so far are kept and Reason is set.
+type RunStatus struct {
+	Complete bool
+	Reason   StopReason
+	// Err is set, with StopInvalidInput, when the run was refused before
+	// the search started.
+	Err error
+}
+
+// searchControl is shared by all workers of one run.
+type searchControl struct {
+	ctx    context.Context
+	limits Limits
+
+	stopped atomic.Bool
+	nodes   atomic.Int64
+	huis    atomic.Int64
+	peak    peakHeap
+
+	mu     sync.Mutex
+	reason StopReason
+}
+
+func newSearchControl(ctx context.Context, limits Limits) *searchControl {
+	return &searchControl{ctx: ctx, limits: limits}
+}
+
+// stop ends the search; the first reason recorded is kept.
+func (c *searchControl) stop(reason StopReason) {
+	c.mark(reason)
+	c.stopped.Store(true)
+}
+
+// mark records that the result is incomplete without ending the search.
+func (c *searchControl) mark(reason StopReason) {
+	c.mu.Lock()
+	if c.reason == "" {
+		c.reason = reason
+	}
+	c.mu.Unlock()
+}
+
+// visit is called once per candidate node and reports whether the search
+// must stop.
+func (c *searchControl) visit() bool {
+	if c.stopped.Load() {
+		return true
+	}
+	n := c.nodes.Add(1)
+	if n%ctxCheckInterval == 0 {
+		c.checkContext()
+	}
+	if n%heapCheckInterval == 0 {
+		if heap := c.peak.sample(); c.limits.MaxHeapBytes > 0 && heap > c.limits.MaxHeapBytes {
+			c.stop(StopMaxHeap)
+		}
+	}
+	return c.stopped.Load()
+}
+
+func (c *searchControl) checkContext() {
+	if err := c.ctx.Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.stop(StopDeadlineExceeded)
+		} else {
+			c.stop(StopCanceled)
+		}
+	}
+}
+
+// acceptHUI counts a found itemset against MaxHUIs and reports whether it
+// may be kept. Only an itemset past the limit ends the search, so a run
+// with exactly MaxHUIs itemsets is complete.
+func (c *searchControl) acceptHUI() bool {
+	if c.limits.MaxHUIs <= 0 {
+		return true
+	}
+	if c.huis.Add(1) > int64(c.limits.MaxHUIs) {
+		c.stop(StopMaxHUIs)
+		return false
+	}
+	return true
+}
+
+// limitResult applies MaxHUIs to a result that is only known at the end of
+// the search, such as the closed or the maximal itemsets.
+func limitResult[T any](c *searchControl, result []T) []T {
+	if c.limits.MaxHUIs <= 0 || len(result) <= c.limits.MaxHUIs {
+		return result
+	}
+	c.mark(StopMaxHUIs)
+	return result[:c.limits.MaxHUIs]
+}
+
+// depthExceeded reports whether itemsets of the given size may not be
+// extended any further.
+func (c *searchControl) depthExceeded(depth int) bool {
+	return c.limits.MaxDepth > 0 && depth >= c.limits.MaxDepth
+}
+
+func (c *searchControl) status() RunStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return RunStatus{Complete: c.reason == "", Reason: c.reason}
+}
+
+func heapBytes() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+// stopped reports whether the run was ended early. A SearchAlgorithms used
+// without EMHUN.RunContext has no control and never stops.
+func (s *SearchAlgorithms) stopped() bool {
+	return s.control != nil && s.control.visit()
+}
+
+// canExtend reports whether an itemset of the given size may be extended. If
+// it may not because of MaxDepth but extensions exist, the run is marked
+// incomplete; reaching Constraints.MaxLength does not.
+func (s *SearchAlgorithms) canExtend(depth int, hasExtensions bool) bool {
+	if s.constraints.MaxLength > 0 && depth >= s.constraints.MaxLength {
+		return false
+	}
+	if s.control == nil || !s.control.depthExceeded(depth) {
+		return true
+	}
+	if hasExtensions {
+		s.control.mark(StopMaxDepth)
+	}
+	return false
+}
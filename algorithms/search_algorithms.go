@@ -1,9 +1,10 @@
 package algorithms
 
 import (
+	"context"
 	"emhun/models"
 	"emhun/utility"
-	"fmt"
+	"log/slog"
 )
 
 type SearchAlgorithms struct {
@@ -13,6 +14,55 @@ type SearchAlgorithms struct {
 	FilteredPrimary     []int
 	FilteredSecondary   []int
 	HighUtilityItemsets []*models.HighUtilityItemset
+
+	// ItemLists holds the single-item utility lists used by EngineUtilityList.
+	ItemLists []*models.UtilityList
+	// ItemNames maps the dense item ids used during mining back to the
+	// dataset ids, see renaming.go.
+	ItemNames []int
+
+	// K > 0 switches to top-k mode, see topk.go.
+	K    int
+	topK huiHeap
+
+	// Maximal reports only the HUIs without a HUI superset, see maximal.go.
+	// It is ignored in top-k mode.
+	Maximal bool
+	maximal *maximalSet
+	// closed is set in closed mode, see closed.go.
+	closed *closedSet
+	// transactions are those the utility lists were built from.
+	transactions []*models.Transaction
+
+	// constraints are EMHUN.Constraints, see constraints.go. η items have
+	// ids from firstEta on; negative, if set, tells which of them are η in
+	// the whole dataset and mixedEta whether some are not. required and
+	// anyOf are MustContain and AnyOf as ascending ids.
+	constraints Constraints
+	firstEta    int
+	negative    []bool
+	mixedEta    bool
+	required    []int
+	anyOf       []int
+
+	// minUtilities are the per-item thresholds by id, nil with a single
+	// threshold, see min_utilities.go.
+	minUtilities []float64
+	suffixMin    []float64
+	aggregate    Aggregate
+
+	// Set on parallel workers only, see parallel.go.
+	queue           *taskQueue
+	splitDepth      int
+	sharedThreshold *sharedThreshold
+	taskKey         []int
+
+	// control carries cancellation and limits, see run_control.go.
+	control  *searchControl
+	counters searchCounters
+
+	// Logger receives the per-candidate traces at debug level.
+	Logger *slog.Logger
 }
 
 func NewSearchAlgorithms(utilityArray *models.UtilityArray) *SearchAlgorithms {
@@ -20,30 +70,45 @@ func NewSearchAlgorithms(utilityArray *models.UtilityArray) *SearchAlgorithms {
 		UtilityArray:        utilityArray,
 		Beta:                make(map[int]bool),
 		HighUtilityItemsets: []*models.HighUtilityItemset{},
+		Logger:              utility.DiscardLogger(),
 	}
 }
 
+// tracing reports whether candidates are logged. The hot paths check it
+// before building the log attributes.
+func (s *SearchAlgorithms) tracing() bool {
+	return s.Logger != nil && s.Logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
 func (s *SearchAlgorithms) Search(eta []int, X map[int]bool, transactions []*models.Transaction, primary []int, secondary []int, minU float64) {
 	if len(primary) == 0 {
 		return
 	}
 
 	for _, item := range primary {
+		if s.stopped() {
+			return
+		}
 
 		s.Beta = copyMap(X)
 		s.Beta[item] = true
 		s.ItemList = mapKeys(s.Beta)
+		if s.pruneByConstraints(s.ItemList, item) {
+			continue
+		}
 
 		projectedDB, utilityBeta := s.projectDatabase(transactions, s.ItemList)
+		if s.subtreeSubsumed(s.ItemList, projectedDB, nil) {
+			continue
+		}
 
-		if utilityBeta >= minU {
-			fmt.Printf("U(%d) = %.2f >= %.2f HUI Found: %v\n", item, utilityBeta, minU, s.Beta)
-			s.HighUtilityItemsets = append(s.HighUtilityItemsets, models.NewHighUtilityItemset(s.ItemList, utilityBeta))
-		} else {
-			fmt.Printf("%.2f < %.2f so %v is not a HUI.\n", utilityBeta, minU, s.Beta)
+		thresholds := s.thresholdsOf(s.ItemList)
+		s.traceCandidate(s.ItemList, utilityBeta, s.huiThreshold(thresholds, minU))
+		if utilityBeta >= s.huiThreshold(thresholds, minU) {
+			s.addHUI(s.ItemList, utilityBeta, projectedDB, nil)
 		}
 
-		if utilityBeta > minU {
+		if len(eta) > 0 && s.canExtendNegative(s.ItemList) && utility.CalculatePositiveUtilityForSet(projectedDB, s.ItemList) >= s.subtreeThreshold(thresholds, s.firstEta, minU) && s.canExtend(len(s.Beta), true) {
 			s.SearchN(eta, s.Beta, projectedDB, minU)
 		}
 
@@ -62,19 +127,31 @@ func (s *SearchAlgorithms) Search(eta []int, X map[int]bool, transactions []*mod
 				rsu := s.UtilityArray.GetRSU(secItem)
 				rlu := s.UtilityArray.GetRLU(secItem)
 
-				if rsu >= minU {
+				if rsu >= s.extensionThreshold(thresholds, secItem, secItem+1, minU) {
 					s.FilteredPrimary = append(s.FilteredPrimary, secItem)
+				} else {
+					s.counters.prunedRSU++
 				}
-				if rlu >= minU {
+				// RLU bao cả các itemset có item nằm giữa item và secItem
+				if rlu >= s.extensionThreshold(thresholds, secItem, item+1, minU) {
 					s.FilteredSecondary = append(s.FilteredSecondary, secItem)
+				} else {
+					s.counters.prunedRLU++
 				}
 			}
 		}
 
-		fmt.Printf("Beta= %v\n", s.Beta)
-		fmt.Printf("Primary%v = %v\n", s.ItemList, s.FilteredPrimary)
-		fmt.Printf("Secondary%v = %v\n", s.ItemList, s.FilteredSecondary)
+		if s.tracing() {
+			s.Logger.Debug("extensions", "beta", s.itemNames(s.ItemList), "primary", s.itemNames(s.FilteredPrimary), "secondary", s.itemNames(s.FilteredSecondary))
+		}
 
+		if !s.canExtend(len(s.Beta), len(s.FilteredPrimary) > 0) {
+			continue
+		}
+		if s.shouldSplit(len(s.Beta)) {
+			s.spawn(searchTask{eta: eta, prefix: s.Beta, transactions: projectedDB, secondary: s.FilteredSecondary, minU: minU}, s.FilteredPrimary)
+			continue
+		}
 		s.Search(eta, s.Beta, projectedDB, s.FilteredPrimary, s.FilteredSecondary, minU)
 	}
 }
@@ -85,18 +162,26 @@ func (s *SearchAlgorithms) SearchN(eta []int, beta map[int]bool, transactions []
 	}
 
 	for _, item := range eta {
+		if s.stopped() {
+			return
+		}
 		betaNew := copyMap(beta)
 		betaNew[item] = true
 
 		itemList := mapKeys(betaNew)
+		if s.pruneByConstraints(itemList, item) {
+			continue
+		}
 
 		projectedDBNew, utilityBetaNew := s.projectDatabase(transactions, itemList)
+		if s.subtreeSubsumed(itemList, projectedDBNew, nil) {
+			continue
+		}
 
-		if utilityBetaNew >= minU {
-			fmt.Printf("U(%d) = %.2f >= %.2f HUI Found: %v\n", item, utilityBetaNew, minU, betaNew)
-			s.HighUtilityItemsets = append(s.HighUtilityItemsets, models.NewHighUtilityItemset(mapKeys(betaNew), utilityBetaNew))
-		} else {
-			fmt.Printf("%.2f < %.2f so %v is not a HUI.\n", utilityBetaNew, minU, betaNew)
+		thresholds := s.thresholdsOf(itemList)
+		s.traceCandidate(itemList, utilityBetaNew, s.huiThreshold(thresholds, minU))
+		if utilityBetaNew >= s.huiThreshold(thresholds, minU) {
+			s.addHUI(mapKeys(betaNew), utilityBetaNew, projectedDBNew, nil)
 		}
 
 		itemIndex := indexOf(eta, item)
@@ -108,16 +193,66 @@ func (s *SearchAlgorithms) SearchN(eta []int, beta map[int]bool, transactions []
 			}
 			if indexOf(eta, secItem) > itemIndex {
 				rsu := s.UtilityArray.GetRSU(secItem)
-				if rsu >= minU {
+				// Danh sách này cũng là ứng viên của mọi nút con, nên tính cả
+				// các item nằm giữa item và secItem
+				if rsu >= s.extensionThreshold(thresholds, secItem, item+1, minU) {
 					filteredPrimary = append(filteredPrimary, secItem)
+				} else {
+					s.counters.prunedRSU++
 				}
 			}
 		}
-		fmt.Printf("Primary = %v\n", filteredPrimary)
-		s.SearchN(filteredPrimary, betaNew, projectedDBNew, minU)
+		if s.tracing() {
+			s.Logger.Debug("negative extensions", "beta", s.itemNames(itemList), "primary", s.itemNames(filteredPrimary))
+		}
+		if s.canExtendNegative(itemList) && s.canExtend(len(betaNew), len(filteredPrimary) > 0) {
+			s.SearchN(filteredPrimary, betaNew, projectedDBNew, minU)
+		}
 	}
 }
 
+// traceCandidate logs whether a visited itemset is a HUI at the current
+// threshold.
+func (s *SearchAlgorithms) traceCandidate(itemset []int, utility float64, minU float64) {
+	if !s.tracing() {
+		return
+	}
+	threshold := s.threshold(minU)
+	if utility >= threshold {
+		s.Logger.Debug("HUI found", "itemset", s.itemNames(itemset), "utility", utility, "threshold", threshold)
+	} else {
+		s.Logger.Debug("not a HUI", "itemset", s.itemNames(itemset), "utility", utility, "threshold", threshold)
+	}
+}
+
+// addHUI records a high utility itemset, either directly or, in top-k mode,
+// through the bounded heap that also raises the threshold. In closed mode it
+// is recorded under its closure and in maximal mode in the set of maximal
+// itemsets. The transactions containing itemset are given either as
+// transactions or, for the utility-list engine, as the tids of list.
+func (s *SearchAlgorithms) addHUI(itemset []int, utility float64, transactions []*models.Transaction, list *models.UtilityList) {
+	if !s.allowed(itemset) {
+		return
+	}
+	if s.closed != nil {
+		s.addClosed(itemset, transactions, list)
+		return
+	}
+	if s.K == 0 && s.maximal == nil && s.control != nil && !s.control.acceptHUI() {
+		return
+	}
+	if s.maximal != nil {
+		s.addMaximal(itemset, utility)
+		return
+	}
+	hui := models.NewHighUtilityItemset(s.itemNames(itemset), utility)
+	if s.K > 0 {
+		s.offerTopK(hui)
+		return
+	}
+	s.HighUtilityItemsets = append(s.HighUtilityItemsets, hui)
+}
+
 // func (s *SearchAlgorithms) projectDatabase(transactions []*models.Transaction, items []int) []*models.Transaction {
 // 	var projectedDB []*models.Transaction
 
@@ -247,12 +382,13 @@ func containsAllItemsMap(items []int, itemset map[int]bool) bool {
 }
 
 func (s *SearchAlgorithms) printProjectedDatabase(projectedDB []*models.Transaction, items []int) {
-	fmt.Printf("\nProjected Database after items %v:\n", items)
+	if !s.tracing() {
+		return
+	}
 	for _, transaction := range projectedDB {
-		fmt.Printf("Items: %v, Utilities: %v, Transaction Utility: %.2f\n",
-			transaction.Items, transaction.Utilities, calculateTransactionUtility(transaction.Utilities))
+		s.Logger.Debug("projected transaction", "after", s.itemNames(items), "items", s.itemNames(transaction.Items),
+			"utilities", transaction.Utilities, "tu", calculateTransactionUtility(transaction.Utilities))
 	}
-	fmt.Println("----------------------------------")
 }
 
 func calculateTransactionUtility(utilities []float64) float64 { // Chuyển sang float64
@@ -0,0 +1,139 @@
+package algorithms
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// Stats describes the last run of EMHUN. It is filled by RunContext and can
+// be marshalled to JSON as is.
+type Stats struct {
+	Transactions         int `json:"transactions"`
+	FilteredTransactions int `json:"filtered_transactions"`
+
+	RhoItems       int `json:"rho_items"`
+	DeltaItems     int `json:"delta_items"`
+	EtaItems       int `json:"eta_items"`
+	SecondaryItems int `json:"secondary_items"`
+	PrimaryItems   int `json:"primary_items"`
+
+	// CandidatesVisited counts the itemsets whose utility was computed.
+	// PrunedByRSU counts extensions not explored because their RSU is below
+	// the threshold, PrunedByRLU items dropped from Secondary because their
+	// RLU is.
+	CandidatesVisited int64 `json:"candidates_visited"`
+	PrunedByRSU       int64 `json:"pruned_by_rsu"`
+	PrunedByRLU       int64 `json:"pruned_by_rlu"`
+	// PrunedByMaximal counts subtrees skipped in maximal mode because they
+	// fit in a maximal HUI already found.
+	PrunedByMaximal int64 `json:"pruned_by_maximal,omitempty"`
+	// PrunedByConstraints counts subtrees skipped because no itemset in them
+	// meets the item constraints.
+	PrunedByConstraints int64 `json:"pruned_by_constraints,omitempty"`
+	HUIs                int   `json:"huis"`
+	ClosedItemsets      int   `json:"closed_itemsets,omitempty"`
+
+	Phases PhaseDurations `json:"phases"`
+	// PeakHeapBytes is the largest live heap sampled during the run.
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+}
+
+// PhaseDurations holds the time spent in each step of RunContext.
+type PhaseDurations struct {
+	// Classify covers dense ids and the ρ/δ/η classification.
+	Classify time.Duration
+	// RTWU covers the RTWU of every item and the Secondary set.
+	RTWU time.Duration
+	// Filter covers filtering, renaming and sorting the transactions.
+	Filter time.Duration
+	// RSU covers the RSU of the Secondary items, the Primary set and, with
+	// EngineUtilityList, building the utility lists.
+	RSU    time.Duration
+	Search time.Duration
+	Total  time.Duration
+}
+
+// MarshalJSON writes the durations as seconds.
+func (p PhaseDurations) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Classify float64 `json:"classify_seconds"`
+		RTWU     float64 `json:"rtwu_seconds"`
+		Filter   float64 `json:"filter_seconds"`
+		RSU      float64 `json:"rsu_seconds"`
+		Search   float64 `json:"search_seconds"`
+		Total    float64 `json:"total_seconds"`
+	}{p.Classify.Seconds(), p.RTWU.Seconds(), p.Filter.Seconds(), p.RSU.Seconds(), p.Search.Seconds(), p.Total.Seconds()})
+}
+
+// collectSearchStats fills the counters of e.Stats once the search is done.
+// Secondary items that are not Primary count as pruned by RSU at the root.
+func (e *EMHUN) collectSearchStats(control *searchControl) {
+	counters := e.SearchAlgorithms.counters
+	e.Stats.CandidatesVisited = control.nodes.Load()
+	e.Stats.PrunedByRSU = counters.prunedRSU + int64(len(e.SortedSecondary)-len(e.PrimaryItems))
+	e.Stats.PrunedByRLU = counters.prunedRLU
+	e.Stats.PrunedByMaximal = counters.prunedMaximal
+	e.Stats.PrunedByConstraints = counters.prunedConstraints
+	e.Stats.HUIs = len(e.SearchAlgorithms.HighUtilityItemsets)
+	control.peak.sample()
+	e.Stats.PeakHeapBytes = control.peak.bytes.Load()
+}
+
+// searchCounters are kept per SearchAlgorithms, so that workers do not
+// contend on them, and summed when the workers are done. Visited candidates
+// are counted by searchControl.
+type searchCounters struct {
+	prunedRSU int64
+	prunedRLU int64
+	// prunedMaximal counts subtrees skipped in maximal mode.
+	prunedMaximal int64
+	// prunedConstraints counts subtrees without an itemset meeting the item
+	// constraints.
+	prunedConstraints int64
+}
+
+func (c *searchCounters) add(other searchCounters) {
+	c.prunedRSU += other.prunedRSU
+	c.prunedRLU += other.prunedRLU
+	c.prunedMaximal += other.prunedMaximal
+	c.prunedConstraints += other.prunedConstraints
+}
+
+// phaseTimer measures consecutive phases of a run.
+type phaseTimer struct {
+	start, last time.Time
+}
+
+func newPhaseTimer() *phaseTimer {
+	now := time.Now()
+	return &phaseTimer{start: now, last: now}
+}
+
+// lap returns the time since the previous lap.
+func (t *phaseTimer) lap() time.Duration {
+	now := time.Now()
+	d := now.Sub(t.last)
+	t.last = now
+	return d
+}
+
+func (t *phaseTimer) total() time.Duration {
+	return time.Since(t.start)
+}
+
+// peakHeap keeps the largest heap size sampled by any worker.
+type peakHeap struct {
+	bytes atomic.Uint64
+}
+
+// sample reads the current heap size, records it and returns it.
+func (p *peakHeap) sample() uint64 {
+	current := heapBytes()
+	for {
+		old := p.bytes.Load()
+		if current <= old || p.bytes.CompareAndSwap(old, current) {
+			return current
+		}
+	}
+}
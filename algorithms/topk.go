@@ -0,0 +1,191 @@
+package algorithms
+
+import (
+	"container/heap"
+	"context"
+	"emhun/models"
+	"emhun/utility"
+	"slices"
+	"sort"
+)
+
+// topKSeedPairItems caps how many items (highest RTWU first) are paired up
+// when seeding the top-k threshold.
+const topKSeedPairItems = 64
+
+// huiHeap is a min-heap on utility holding the best itemsets found so far.
+type huiHeap []*models.HighUtilityItemset
+
+func (h huiHeap) Len() int           { return len(h) }
+func (h huiHeap) Less(i, j int) bool { return h[i].Utility < h[j].Utility }
+func (h huiHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *huiHeap) Push(x any) {
+	*h = append(*h, x.(*models.HighUtilityItemset))
+}
+
+func (h *huiHeap) Pop() any {
+	old := *h
+	n := len(old)
+	hui := old[n-1]
+	*h = old[:n-1]
+	return hui
+}
+
+// threshold returns the minimum utility used for reporting and pruning. In
+// top-k mode it is raised to the k-th best utility once k itemsets are known.
+func (s *SearchAlgorithms) threshold(minU float64) float64 {
+	if s.sharedThreshold != nil {
+		minU = max(minU, s.sharedThreshold.get())
+	}
+	if s.K > 0 && len(s.topK) == s.K {
+		return max(minU, s.topK[0].Utility)
+	}
+	return minU
+}
+
+func (s *SearchAlgorithms) offerTopK(hui *models.HighUtilityItemset) {
+	if hui.Utility <= 0 {
+		return
+	}
+	if len(s.topK) < s.K {
+		heap.Push(&s.topK, hui)
+	} else if hui.Utility > s.topK[0].Utility {
+		s.topK[0] = hui
+		heap.Fix(&s.topK, 0)
+	} else {
+		return
+	}
+	if s.sharedThreshold != nil && len(s.topK) == s.K {
+		s.sharedThreshold.raise(s.topK[0].Utility)
+	}
+}
+
+// topKResults returns the retained itemsets by decreasing utility.
+func (s *SearchAlgorithms) topKResults() []*models.HighUtilityItemset {
+	results := make([]*models.HighUtilityItemset, len(s.topK))
+	copy(results, s.topK)
+	sortByUtility(results)
+	return results
+}
+
+// sortByUtility orders itemsets by decreasing utility, ties by itemset.
+func sortByUtility(huis []*models.HighUtilityItemset) {
+	sort.Slice(huis, func(i, j int) bool {
+		if huis[i].Utility != huis[j].Utility {
+			return huis[i].Utility > huis[j].Utility
+		}
+		return slices.Compare(huis[i].Itemset, huis[j].Itemset) < 0
+	})
+}
+
+// RunTopK mines the k itemsets with the highest (positive) utility instead
+// of every itemset above MinUtility. The threshold starts at zero, is seeded
+// from the utilities of single items and of pairs of high-RTWU items, and is
+// raised during the search as the k-th best utility improves. Afterwards
+// MinUtility holds the final threshold, i.e. the utility of the k-th itemset.
+func (e *EMHUN) RunTopK(k int) {
+	e.RunTopKContext(context.Background(), k)
+}
+
+// RunTopKContext is RunTopK with the cancellation and limits of RunContext.
+func (e *EMHUN) RunTopKContext(ctx context.Context, k int) RunStatus {
+	e.SearchAlgorithms.K = k
+	e.MinUtility = e.seedTopKThreshold(k)
+	return e.RunContext(ctx)
+}
+
+// seedTopKThreshold returns the k-th largest utility among all single items
+// and the pairs formed by the topKSeedPairItems items of highest RTWU. These
+// are real itemsets, so the k-th best utility overall is at least this value.
+// Itemsets that e.Constraints exclude are left out, and pairs too when
+// Limits.MaxDepth keeps the search to single items.
+func (e *EMHUN) seedTopKThreshold(k int) float64 {
+	itemUtilities := make(map[int]float64)
+	rtwu := make(map[int]float64)
+	hasPositive := make(map[int]bool)
+	for _, transaction := range e.Transactions {
+		rtu := utility.CalculateRTUForTransaction(transaction)
+		for i, item := range transaction.Items {
+			itemUtilities[item] += transaction.Utilities[i]
+			rtwu[item] += rtu
+			if transaction.Utilities[i] > 0 {
+				hasPositive[item] = true
+			}
+		}
+	}
+	// Item không có utility dương nào được tính là η; với item chỉ có utility 0
+	// điều này chỉ làm ngưỡng khởi đầu thấp hơn
+	negatives := func(items ...int) int {
+		n := 0
+		for _, item := range items {
+			if !hasPositive[item] {
+				n++
+			}
+		}
+		return n
+	}
+
+	var candidates []float64
+	for item, u := range itemUtilities {
+		if e.Constraints.allows(1, negatives(item)) && e.Constraints.allowsItems([]int{item}) {
+			candidates = append(candidates, u)
+		}
+	}
+
+	pairItems := make([]int, 0, len(rtwu))
+	for item := range rtwu {
+		pairItems = append(pairItems, item)
+	}
+	sort.Slice(pairItems, func(i, j int) bool {
+		if rtwu[pairItems[i]] != rtwu[pairItems[j]] {
+			return rtwu[pairItems[i]] > rtwu[pairItems[j]]
+		}
+		return pairItems[i] < pairItems[j]
+	})
+	if len(pairItems) > topKSeedPairItems {
+		pairItems = pairItems[:topKSeedPairItems]
+	}
+	if e.Limits.MaxDepth == 1 {
+		// Tìm kiếm không mở rộng quá một item nên không đạt tới các cặp;
+		// dùng chúng sẽ nâng ngưỡng trên itemset thứ k tìm được
+		pairItems = nil
+	}
+	selected := convertSliceToMap(pairItems)
+
+	// Item lặp lại trong giao dịch được cộng dồn trước, để không tạo cặp [x,x]
+	// và không tính hai lần item còn lại của cặp
+	pairUtilities := make(map[[2]int]float64)
+	for _, transaction := range e.Transactions {
+		var items []int
+		var utilities []float64
+		for i, item := range transaction.Items {
+			if !selected[item] {
+				continue
+			}
+			if j := slices.Index(items, item); j >= 0 {
+				utilities[j] += transaction.Utilities[i]
+				continue
+			}
+			items = append(items, item)
+			utilities = append(utilities, transaction.Utilities[i])
+		}
+		for a := 0; a < len(items); a++ {
+			for b := a + 1; b < len(items); b++ {
+				key := [2]int{min(items[a], items[b]), max(items[a], items[b])}
+				pairUtilities[key] += utilities[a] + utilities[b]
+			}
+		}
+	}
+	for pair, u := range pairUtilities {
+		if e.Constraints.allows(2, negatives(pair[0], pair[1])) && e.Constraints.allowsItems(pair[:]) {
+			candidates = append(candidates, u)
+		}
+	}
+
+	if len(candidates) < k {
+		return 0
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(candidates)))
+	return max(0, candidates[k-1])
+}
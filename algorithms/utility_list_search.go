@@ -0,0 +1,219 @@
+package algorithms
+
+import (
+	"emhun/models"
+	"math"
+)
+
+// Engine selects how SearchAlgorithms computes utilities and upper bounds.
+type Engine string
+
+const (
+	// EngineProjection re-projects the database for every candidate itemset.
+	EngineProjection Engine = "projection"
+	// EngineUtilityList joins per-itemset utility lists instead.
+	EngineUtilityList Engine = "utility-list"
+)
+
+// BuildUtilityLists builds the single-item utility lists over the filtered and
+// sorted transactions. The transaction index is used as tid.
+func (s *SearchAlgorithms) BuildUtilityLists(transactions []*models.Transaction) {
+	s.ItemLists = nil
+	s.transactions = transactions
+	for tid, transaction := range transactions {
+		remaining := 0.0
+		for i := len(transaction.Items) - 1; i >= 0; i-- {
+			item := transaction.Items[i]
+			for item >= len(s.ItemLists) {
+				s.ItemLists = append(s.ItemLists, models.NewUtilityList())
+			}
+			s.ItemLists[item].Add(tid, transaction.Utilities[i], remaining)
+			if transaction.Utilities[i] > 0 {
+				remaining += transaction.Utilities[i]
+			}
+		}
+	}
+}
+
+func (s *SearchAlgorithms) itemList(item int) *models.UtilityList {
+	if item >= len(s.ItemLists) {
+		return nil
+	}
+	return s.ItemLists[item]
+}
+
+// SearchUtilityList is the utility-list counterpart of Search. X is the
+// current prefix in processing order and list its utility list; a nil list
+// stands for the empty prefix.
+func (s *SearchAlgorithms) SearchUtilityList(eta []int, X []int, list *models.UtilityList, primary []int, secondary []int, minU float64) {
+	if len(primary) == 0 {
+		return
+	}
+
+	for _, item := range primary {
+		if s.stopped() {
+			return
+		}
+		beta := appendItem(X, item)
+		if s.pruneByConstraints(beta, item) {
+			continue
+		}
+		betaList := s.joinUtilityLists(list, s.itemList(item))
+		utilityBeta := betaList.SumUtility()
+		if s.subtreeSubsumed(beta, nil, betaList) {
+			continue
+		}
+
+		thresholds := s.thresholdsOf(beta)
+		s.traceCandidate(beta, utilityBeta, s.huiThreshold(thresholds, minU))
+		if utilityBeta >= s.huiThreshold(thresholds, minU) {
+			s.addHUI(beta, utilityBeta, nil, betaList)
+		}
+
+		if len(eta) > 0 && s.canExtendNegative(beta) && betaList.SumPositiveUtility() >= s.subtreeThreshold(thresholds, s.firstEta, minU) && s.canExtend(len(beta), true) {
+			s.SearchNUtilityList(eta, beta, betaList, minU)
+		}
+
+		filteredPrimary := []int{}
+		filteredSecondary := []int{}
+		itemIndex := indexOf(secondary, item)
+		for i := itemIndex + 1; i < len(secondary); i++ {
+			secItem := secondary[i]
+			rsu, rlu := s.calculateBoundsFromLists(betaList, s.itemList(secItem))
+			s.UtilityArray.SetRSU(secItem, rsu)
+			s.UtilityArray.SetRLU(secItem, rlu)
+
+			if rsu >= s.extensionThreshold(thresholds, secItem, secItem+1, minU) {
+				filteredPrimary = append(filteredPrimary, secItem)
+			} else {
+				s.counters.prunedRSU++
+			}
+			if rlu >= s.extensionThreshold(thresholds, secItem, item+1, minU) {
+				filteredSecondary = append(filteredSecondary, secItem)
+			} else {
+				s.counters.prunedRLU++
+			}
+		}
+
+		if s.tracing() {
+			s.Logger.Debug("extensions", "beta", s.itemNames(beta), "primary", s.itemNames(filteredPrimary), "secondary", s.itemNames(filteredSecondary))
+		}
+
+		if !s.canExtend(len(beta), len(filteredPrimary) > 0) {
+			continue
+		}
+		if s.shouldSplit(len(beta)) {
+			s.spawn(searchTask{eta: eta, prefixItems: beta, list: betaList, secondary: filteredSecondary, minU: minU}, filteredPrimary)
+			continue
+		}
+		s.SearchUtilityList(eta, beta, betaList, filteredPrimary, filteredSecondary, minU)
+	}
+}
+
+// SearchNUtilityList is the utility-list counterpart of SearchN.
+func (s *SearchAlgorithms) SearchNUtilityList(eta []int, beta []int, list *models.UtilityList, minU float64) {
+	if len(eta) == 0 {
+		return
+	}
+
+	for itemIndex, item := range eta {
+		if s.stopped() {
+			return
+		}
+		betaNew := appendItem(beta, item)
+		if s.pruneByConstraints(betaNew, item) {
+			continue
+		}
+		betaNewList := s.joinUtilityLists(list, s.itemList(item))
+		utilityBetaNew := betaNewList.SumUtility()
+		if s.subtreeSubsumed(betaNew, nil, betaNewList) {
+			continue
+		}
+
+		thresholds := s.thresholdsOf(betaNew)
+		s.traceCandidate(betaNew, utilityBetaNew, s.huiThreshold(thresholds, minU))
+		if utilityBetaNew >= s.huiThreshold(thresholds, minU) {
+			s.addHUI(betaNew, utilityBetaNew, nil, betaNewList)
+		}
+
+		filteredPrimary := []int{}
+		for _, secItem := range eta[itemIndex+1:] {
+			rsu, _ := s.calculateBoundsFromLists(betaNewList, s.itemList(secItem))
+			s.UtilityArray.SetRSU(secItem, rsu)
+			// Danh sách này cũng là ứng viên của mọi nút con
+			if rsu >= s.extensionThreshold(thresholds, secItem, item+1, minU) {
+				filteredPrimary = append(filteredPrimary, secItem)
+			} else {
+				s.counters.prunedRSU++
+			}
+		}
+		if s.tracing() {
+			s.Logger.Debug("negative extensions", "beta", s.itemNames(betaNew), "primary", s.itemNames(filteredPrimary))
+		}
+		if s.canExtendNegative(betaNew) && s.canExtend(len(betaNew), len(filteredPrimary) > 0) {
+			s.SearchNUtilityList(filteredPrimary, betaNew, betaNewList, minU)
+		}
+	}
+}
+
+// joinUtilityLists builds the list of X ∪ {z} from the list of X and the
+// single-item list of z, where z comes after every item of X.
+func (s *SearchAlgorithms) joinUtilityLists(xList, zList *models.UtilityList) *models.UtilityList {
+	joined := models.NewUtilityList()
+	if zList == nil {
+		return joined
+	}
+	if xList == nil {
+		joined.Entries = append(joined.Entries, zList.Entries...)
+		return joined
+	}
+
+	i, j := 0, 0
+	for i < len(xList.Entries) && j < len(zList.Entries) {
+		x, z := xList.Entries[i], zList.Entries[j]
+		switch {
+		case x.Tid < z.Tid:
+			i++
+		case x.Tid > z.Tid:
+			j++
+		default:
+			joined.Add(x.Tid, x.Utility+z.Utility, z.RemainingUtility)
+			i++
+			j++
+		}
+	}
+	return joined
+}
+
+// calculateBoundsFromLists returns RSU(X, z) and RLU(X, z) with the same
+// clamped definitions as utility.CalculateRSUForAllItem and
+// utility.CalculateRLUForAllItem.
+func (s *SearchAlgorithms) calculateBoundsFromLists(xList, zList *models.UtilityList) (float64, float64) {
+	rsu, rlu := 0.0, 0.0
+	if zList == nil {
+		return rsu, rlu
+	}
+
+	i, j := 0, 0
+	for i < len(xList.Entries) && j < len(zList.Entries) {
+		x, z := xList.Entries[i], zList.Entries[j]
+		switch {
+		case x.Tid < z.Tid:
+			i++
+		case x.Tid > z.Tid:
+			j++
+		default:
+			rsu += math.Max(0, x.Utility+z.Utility+z.RemainingUtility)
+			rlu += math.Max(0, x.Utility+x.RemainingUtility)
+			i++
+			j++
+		}
+	}
+	return rsu, rlu
+}
+
+func appendItem(items []int, item int) []int {
+	result := make([]int, len(items), len(items)+1)
+	copy(result, items)
+	return append(result, item)
+}
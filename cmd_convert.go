@@ -0,0 +1,44 @@
+package main
+
+import (
+	"emhun/dataset"
+	"flag"
+	"fmt"
+	"io"
+)
+
+func runConvert(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "dataset file in items:TU:utilities format (required)")
+	output := fs.String("output", "", "binary dataset file to write (required)")
+	strict := fs.Bool("strict", false, "fail on the first malformed or inconsistent line instead of skipping it")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *input == "" || *output == "" {
+		fmt.Fprintln(stderr, "convert: --input and --output are required")
+		fs.Usage()
+		return exitUsage
+	}
+
+	mode := dataset.Lenient
+	if *strict {
+		mode = dataset.Strict
+	}
+	data, err := dataset.ReadFile(*input, mode)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading dataset:", err)
+		return exitError
+	}
+	for _, issue := range data.Report.Issues {
+		fmt.Fprintln(stderr, issue)
+	}
+
+	if err := dataset.WriteBinaryFile(*output, data); err != nil {
+		fmt.Fprintln(stderr, "Error writing dataset:", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "%d transactions written to %s\n", len(data.Transactions), *output)
+	return exitOK
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"emhun/generator"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func runGenerate(args []string, stdout, stderr io.Writer) int {
+	defaults := generator.DefaultConfig()
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("output", "", "dataset file to write (default: stdout)")
+	transactions := fs.Int("transactions", defaults.Transactions, "number of transactions")
+	items := fs.Int("items", defaults.Items, "number of distinct items")
+	avgLength := fs.Float64("avg-length", defaults.AvgLength, "average number of items per transaction")
+	zipf := fs.Float64("zipf", defaults.Zipf, "Zipf exponent of the item popularity, 0 for uniform")
+	positive := fs.Float64("positive", defaults.Positive, "fraction of positive-only (ρ) items")
+	hybrid := fs.Float64("hybrid", defaults.Hybrid, "fraction of hybrid (δ) items")
+	negative := fs.Float64("negative", defaults.Negative, "fraction of negative-only (η) items")
+	hybridNegative := fs.Float64("hybrid-negative", defaults.HybridNegative, "probability that an occurrence of a hybrid item is negative")
+	maxUtility := fs.Float64("max-utility", defaults.MaxUtility, "largest absolute utility of an item occurrence")
+	decimals := fs.Int("decimals", defaults.Decimals, "decimal places of the utilities")
+	seed := fs.Int64("seed", defaults.Seed, "random seed; the same flags and seed give the same file")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	config := generator.Config{
+		Transactions:   *transactions,
+		Items:          *items,
+		AvgLength:      *avgLength,
+		Zipf:           *zipf,
+		Positive:       *positive,
+		Hybrid:         *hybrid,
+		Negative:       *negative,
+		HybridNegative: *hybridNegative,
+		MaxUtility:     *maxUtility,
+		Decimals:       *decimals,
+		Seed:           *seed,
+	}
+	if _, err := generator.New(config); err != nil {
+		fmt.Fprintln(stderr, "generate:", err)
+		return exitUsage
+	}
+
+	if *output == "" {
+		if err := generator.Write(stdout, config); err != nil {
+			fmt.Fprintln(stderr, "Error writing dataset:", err)
+			return exitError
+		}
+		return exitOK
+	}
+	if err := writeGeneratedFile(*output, config); err != nil {
+		fmt.Fprintln(stderr, "Error writing dataset:", err)
+		return exitError
+	}
+	fmt.Fprintf(stdout, "%d transactions written to %s\n", config.Transactions, *output)
+	return exitOK
+}
+
+func writeGeneratedFile(path string, config generator.Config) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := generator.Write(file, config); err != nil {
+		return err
+	}
+	return file.Close()
+}
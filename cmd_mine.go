@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"emhun/algorithms"
+	"emhun/dataset"
+	"emhun/export"
+	"emhun/models"
+	"emhun/utility"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runMine(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("mine", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "dataset file in items:TU:utilities format")
+	quantities := fs.String("quantities", "", "dataset file of item:quantity pairs, used with --profits instead of --input")
+	profits := fs.String("profits", "", "unit-profit table with one \"item profit\" pair per line")
+	minUtility := fs.Float64("min-util", 0, "absolute minimum utility threshold")
+	minUtilityRatio := fs.Float64("min-util-ratio", 0, "minimum utility as a fraction of the total positive utility, e.g. 0.01")
+	minUtilTable := fs.String("min-util-table", "", "per-item minimum utilities, one \"item threshold\" pair per line; other items use --min-util")
+	aggregate := fs.String("min-util-aggregate", string(algorithms.AggregateMin), "threshold of an itemset from those of its items: min, max or avg")
+	topK := fs.Int("top-k", 0, "mine the k itemsets with the highest utility instead of using a threshold")
+	engine := fs.String("engine", string(algorithms.EngineProjection), "search engine: projection or utility-list")
+	workers := fs.Int("workers", 1, "number of goroutines exploring the search tree")
+	splitDepth := fs.Int("split-depth", 1, "with --workers, also run the subtrees of itemsets shorter than this as separate tasks")
+	timeout := fs.Duration("timeout", 0, "stop the search after this long and keep the partial result, e.g. 10m")
+	maxHUIs := fs.Int("max-huis", 0, "stop the search when more than this many itemsets are found")
+	maximal := fs.Bool("maximal", false, "report only the HUIs that have no HUI superset")
+	closed := fs.Bool("closed", false, "report only the closed HUIs, with their support; use the json format to recover the HUIs later")
+	minLength := fs.Int("min-length", 0, "report only itemsets with at least this many items")
+	maxLength := fs.Int("max-length", 0, "report only itemsets with at most this many items; longer ones are not explored")
+	maxNegative := fs.Int("max-negative-items", -1, "report only itemsets with at most this many negative-only items (-1: no limit)")
+	mustContain := fs.String("must-contain", "", "comma-separated items every reported itemset must contain")
+	mustNotContain := fs.String("must-not-contain", "", "comma-separated items to ignore entirely")
+	anyOf := fs.String("any-of", "", "comma-separated items of which every reported itemset must contain at least one")
+	maxDepth := fs.Int("max-depth", 0, "do not extend itemsets beyond this many items")
+	maxHeapMB := fs.Uint64("max-heap-mb", 0, "stop the search once the heap exceeds this many MiB")
+	output := fs.String("output", "", "result file (default: stdout)")
+	format := fs.String("format", "text", "result format: "+strings.Join(export.Formats(), ", "))
+	exact := fs.Bool("exact", false, "read utilities as exact decimals with --decimals places instead of float64")
+	decimals := fs.Int("decimals", 2, "with --exact, the number of decimal places kept")
+	strict := fs.Bool("strict", false, "fail on the first malformed or inconsistent line instead of skipping it")
+	quiet := fs.Bool("quiet", false, "do not print the loaded transactions and progress messages")
+	statsJSON := fs.String("stats-json", "", "write the mining statistics as JSON to this file (- for stdout)")
+	logLevel := fs.String("log-level", "", "stderr log level: debug, info, warn or error (default info, warn with --quiet)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	switch {
+	case *input != "" && (*quantities != "" || *profits != ""):
+		fmt.Fprintln(stderr, "mine: --input cannot be combined with --quantities or --profits")
+		return exitUsage
+	case *input == "" && (*quantities == "") != (*profits == ""):
+		fmt.Fprintln(stderr, "mine: --quantities and --profits must be given together")
+		return exitUsage
+	case *input == "" && *quantities == "":
+		fmt.Fprintln(stderr, "mine: --input or --quantities with --profits is required")
+		fs.Usage()
+		return exitUsage
+	}
+	switch {
+	case *topK < 0:
+		fmt.Fprintln(stderr, "mine: --top-k must be greater than 0")
+		return exitUsage
+	case *topK > 0:
+		if *minUtility != 0 || *minUtilityRatio != 0 {
+			fmt.Fprintln(stderr, "mine: --top-k cannot be combined with --min-util or --min-util-ratio")
+			return exitUsage
+		}
+	case *minUtility != 0 && *minUtilityRatio != 0:
+		fmt.Fprintln(stderr, "mine: --min-util and --min-util-ratio are mutually exclusive")
+		return exitUsage
+	case *minUtilityRatio != 0:
+		if *minUtilityRatio < 0 || *minUtilityRatio > 1 {
+			fmt.Fprintln(stderr, "mine: --min-util-ratio must be in (0, 1]")
+			return exitUsage
+		}
+	case *minUtility <= 0:
+		fmt.Fprintln(stderr, "mine: one of --min-util, --min-util-ratio or --top-k is required and must be greater than 0")
+		return exitUsage
+	}
+	if *closed && *topK > 0 {
+		fmt.Fprintln(stderr, "mine: --closed cannot be combined with --top-k")
+		return exitUsage
+	}
+	if *maximal && (*topK > 0 || *closed) {
+		fmt.Fprintln(stderr, "mine: --maximal cannot be combined with --top-k or --closed")
+		return exitUsage
+	}
+	constraints := algorithms.Constraints{MinLength: *minLength, MaxLength: *maxLength}
+	for _, list := range []struct {
+		flag   string
+		value  string
+		target *[]int
+	}{
+		{"--must-contain", *mustContain, &constraints.MustContain},
+		{"--must-not-contain", *mustNotContain, &constraints.MustNotContain},
+		{"--any-of", *anyOf, &constraints.AnyOf},
+	} {
+		items, err := parseItemList(list.value)
+		if err != nil {
+			fmt.Fprintf(stderr, "mine: %s: %v\n", list.flag, err)
+			return exitUsage
+		}
+		*list.target = items
+	}
+	switch {
+	case *maxNegative == 0:
+		constraints.MaxNegativeItems = algorithms.NoNegativeItems
+	case *maxNegative > 0:
+		constraints.MaxNegativeItems = *maxNegative
+	case *maxNegative < -1:
+		fmt.Fprintln(stderr, "mine: --max-negative-items must be at least -1")
+		return exitUsage
+	}
+	if err := constraints.Validate(); err != nil {
+		fmt.Fprintln(stderr, "mine:", err)
+		return exitUsage
+	}
+	if *closed && !constraints.IsZero() {
+		// Không thể khôi phục HUI từ tập đóng khi một số HUI bị loại bỏ
+		fmt.Fprintln(stderr, "mine: --closed cannot be combined with itemset constraints")
+		return exitUsage
+	}
+	if *engine != string(algorithms.EngineProjection) && *engine != string(algorithms.EngineUtilityList) {
+		fmt.Fprintf(stderr, "mine: unknown engine %q\n", *engine)
+		return exitUsage
+	}
+	itemsetAggregate, err := algorithms.ParseAggregate(*aggregate)
+	if err != nil {
+		fmt.Fprintln(stderr, "mine:", err)
+		return exitUsage
+	}
+	if *minUtilTable != "" && (*topK > 0 || *closed) {
+		fmt.Fprintln(stderr, "mine: --min-util-table cannot be combined with --top-k or --closed")
+		return exitUsage
+	}
+	if *workers < 1 {
+		fmt.Fprintln(stderr, "mine: --workers must be at least 1")
+		return exitUsage
+	}
+	if *logLevel == "" {
+		*logLevel = "info"
+		if *quiet {
+			*logLevel = "warn"
+		}
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		fmt.Fprintf(stderr, "mine: unknown log level %q\n", *logLevel)
+		return exitUsage
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level}))
+
+	if *exact && (*decimals < 0 || *decimals > 18) {
+		fmt.Fprintln(stderr, "mine: --decimals must be between 0 and 18")
+		return exitUsage
+	}
+	var minUtilities map[int]float64
+	if *minUtilTable != "" {
+		minUtilities, err = dataset.ReadThresholdTable(*minUtilTable)
+		if err != nil {
+			fmt.Fprintln(stderr, "Error reading minimum utilities:", err)
+			return exitError
+		}
+		for item, threshold := range minUtilities {
+			if threshold <= 0 {
+				fmt.Fprintf(stderr, "mine: minimum utility of item %d must be greater than 0\n", item)
+				return exitUsage
+			}
+			if *exact {
+				// Cùng cách làm tròn lên như exactThreshold
+				scaled, err := dataset.ParseFixedCeil(strconv.FormatFloat(threshold, 'f', -1, 64), *decimals)
+				if err != nil {
+					fmt.Fprintf(stderr, "mine: minimum utility of item %d: %v\n", item, err)
+					return exitUsage
+				}
+				minUtilities[item] = float64(scaled)
+			}
+		}
+	}
+	writer, err := export.Lookup(*format)
+	if err != nil {
+		fmt.Fprintln(stderr, "mine:", err)
+		return exitUsage
+	}
+
+	// Đo thời gian bắt đầu
+	startTime := time.Now()
+
+	mode := dataset.Lenient
+	if *strict {
+		mode = dataset.Strict
+	}
+	exactDecimals := -1
+	if *exact {
+		exactDecimals = *decimals
+	}
+	data, err := loadDataset(*input, *quantities, *profits, mode, exactDecimals)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading transactions:", err)
+		return exitError
+	}
+	for _, issue := range data.Report.Issues {
+		logger.Warn("dataset issue", "error", issue)
+	}
+	transactions := data.Transactions
+	if !*quiet {
+		fmt.Fprintln(stdout, "Transactions vừa đọc được:")
+		for i, transaction := range transactions {
+			fmt.Fprintf(stdout, "Transaction %d: %s\n", i+1, transaction)
+		}
+	}
+
+	var emhun *algorithms.EMHUN
+	switch {
+	case *exact && *topK == 0:
+		threshold, err := exactThreshold(transactions, *minUtility, *minUtilityRatio, *decimals)
+		if err != nil {
+			fmt.Fprintln(stderr, "mine: threshold:", err)
+			return exitUsage
+		}
+		emhun = algorithms.NewEMHUN(transactions, threshold)
+		emhun.MinUtilityRatio = *minUtilityRatio
+	case *minUtilityRatio != 0:
+		emhun = algorithms.NewEMHUNWithRatio(transactions, *minUtilityRatio)
+	default:
+		emhun = algorithms.NewEMHUN(transactions, *minUtility)
+	}
+	scale := 1.0
+	if *exact {
+		scale = math.Pow10(*decimals)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	emhun.Limits = algorithms.Limits{
+		MaxHUIs:      *maxHUIs,
+		MaxDepth:     *maxDepth,
+		MaxHeapBytes: *maxHeapMB << 20,
+	}
+	emhun.Logger = logger
+	emhun.Closed = *closed
+	emhun.Constraints = constraints
+	emhun.MinUtilities = minUtilities
+	emhun.Aggregate = itemsetAggregate
+	emhun.SearchAlgorithms.Maximal = *maximal
+	emhun.Engine = algorithms.Engine(*engine)
+	emhun.Workers = *workers
+	emhun.SplitDepth = *splitDepth
+	emhun.Exact = *exact
+	if *topK > 0 {
+		emhun.RunTopKContext(ctx, *topK)
+	} else {
+		if !*quiet {
+			fmt.Fprintf(stdout, "Min utility: %.2f\n", emhun.MinUtility/scale)
+		}
+		emhun.RunContext(ctx)
+	}
+
+	if err := emhun.Status.Err; err != nil {
+		fmt.Fprintln(stderr, "mine:", err)
+		return exitError
+	}
+
+	elapsedTime := time.Since(startTime).Seconds()
+
+	// Bộ nhớ sử dụng là heap lớn nhất đo được trong lúc chạy thuật toán
+	allocatedMemory := emhun.Stats.PeakHeapBytes / 1024
+
+	if !*quiet {
+		fmt.Fprintf(stdout, "\nThời gian chạy thuật toán: %.6f s\n", elapsedTime)
+		fmt.Fprintf(stdout, "Bộ nhớ sử dụng: %d KB\n", allocatedMemory)
+		fmt.Fprintln(stdout, "\nFinished executing EMHUN algorithm.")
+	}
+
+	result := export.NewResult(emhun, *input+*quantities, elapsedTime, allocatedMemory)
+	result.ItemNames = data.ItemNames
+	if *exact {
+		result.Unscale(*decimals)
+	}
+	if *output == "" {
+		err = writer.Write(stdout, result)
+	} else {
+		err = writeResultsToFile(writer, result, *output)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error writing results:", err)
+		return exitError
+	}
+
+	if *statsJSON != "" {
+		if err := writeStatsJSON(stdout, *statsJSON, emhun.Stats); err != nil {
+			fmt.Fprintln(stderr, "Error writing stats:", err)
+			return exitError
+		}
+	}
+
+	if !*quiet && *output != "" {
+		fmt.Fprintln(stdout, "Results written to", *output)
+	}
+	if !emhun.Status.Complete {
+		fmt.Fprintf(stderr, "mine: search incomplete (%s), results are partial\n", emhun.Status.Reason)
+		return exitIncomplete
+	}
+	return exitOK
+}
+
+// parseItemList parses a comma-separated list of item ids.
+func parseItemList(list string) ([]int, error) {
+	var items []int
+	for _, field := range strings.Split(list, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		item, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid item %q", field)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// exactThreshold converts --min-util or --min-util-ratio to the scaled integer
+// units of an exact-mode dataset, rounding up: with integer utilities,
+// u >= t holds exactly when u >= ceil(t).
+func exactThreshold(transactions []*models.Transaction, minUtility, minUtilityRatio float64, decimals int) (float64, error) {
+	var threshold int64
+	var err error
+	if minUtilityRatio != 0 {
+		// Tổng tiện ích dương là số nguyên chính xác trong chế độ exact
+		total := int64(utility.CalculateTotalRTU(transactions))
+		threshold, err = dataset.FixedRatioCeil(strconv.FormatFloat(minUtilityRatio, 'f', -1, 64), total)
+	} else {
+		threshold, err = dataset.ParseFixedCeil(strconv.FormatFloat(minUtility, 'f', -1, 64), decimals)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return float64(threshold), nil
+}
+
+// loadDataset reads either a utility dataset or a quantity dataset with its
+// profit table, in exact mode unless exactDecimals is negative.
+func loadDataset(input, quantities, profits string, mode dataset.Mode, exactDecimals int) (*dataset.Dataset, error) {
+	if input != "" && exactDecimals >= 0 {
+		return dataset.ReadFileExact(input, mode, exactDecimals)
+	}
+	if input != "" {
+		return dataset.ReadFile(input, mode)
+	}
+	db, err := dataset.ReadQuantityFile(quantities, mode)
+	if err != nil {
+		return nil, err
+	}
+	table, err := dataset.ReadProfitTable(profits)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := db.Apply(table)
+	if err != nil {
+		return nil, err
+	}
+	data := &dataset.Dataset{Transactions: transactions, Report: db.Report}
+	if exactDecimals >= 0 {
+		if err := data.ScaleExact(exactDecimals); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// writeStatsJSON writes stats to path, or to stdout when path is "-".
+func writeStatsJSON(stdout io.Writer, path string, stats algorithms.Stats) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"emhun/algorithms"
+	"emhun/export"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func runRecover(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("recover", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "result of 'mine --closed --format json' (required)")
+	minUtility := fs.Float64("min-util", 0, "threshold of the recovered HUIs (default: the threshold of the closed run); may only be raised")
+	output := fs.String("output", "", "result file (default: stdout)")
+	format := fs.String("format", "text", "result format: "+strings.Join(export.Formats(), ", "))
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *input == "" {
+		fmt.Fprintln(stderr, "recover: --input is required")
+		fs.Usage()
+		return exitUsage
+	}
+	writer, err := export.Lookup(*format)
+	if err != nil {
+		fmt.Fprintln(stderr, "recover:", err)
+		return exitUsage
+	}
+
+	file, err := os.Open(*input)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading results:", err)
+		return exitError
+	}
+	result, err := export.ReadJSON(file)
+	file.Close()
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading results:", err)
+		return exitError
+	}
+	if result.Closed == nil {
+		fmt.Fprintln(stderr, "recover: the input was not mined with --closed")
+		return exitUsage
+	}
+	// Các HUI có ngưỡng thấp hơn không có trong tập đóng
+	if *minUtility != 0 && *minUtility < result.MinUtility {
+		fmt.Fprintf(stderr, "recover: --min-util must be at least %g, the threshold of the closed run\n", result.MinUtility)
+		return exitUsage
+	}
+	if *minUtility != 0 {
+		result.MinUtility = *minUtility
+		result.MinUtilityRatio = 0
+	}
+
+	result.Itemsets = algorithms.RecoverHUIs(result.Closed, result.MinUtility)
+	result.Closed = nil
+	if *output == "" {
+		err = writer.Write(stdout, result)
+	} else {
+		err = writeResultsToFile(writer, result, *output)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Error writing results:", err)
+		return exitError
+	}
+	return exitOK
+}
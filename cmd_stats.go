@@ -0,0 +1,260 @@
+package main
+
+import (
+	"emhun/algorithms"
+	"emhun/dataset"
+	"emhun/models"
+	"emhun/utility"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// datasetStats describes a dataset, to choose thresholds before mining.
+type datasetStats struct {
+	Dataset         string  `json:"dataset"`
+	Transactions    int     `json:"transactions"`
+	Skipped         int     `json:"skipped_lines"`
+	DistinctItems   int     `json:"distinct_items"`
+	AverageLength   float64 `json:"average_length"`
+	MaxLength       int     `json:"max_length"`
+	Density         float64 `json:"density"`
+	TotalUtility    float64 `json:"total_utility"`
+	PositiveUtility float64 `json:"positive_utility"`
+	NegativeUtility float64 `json:"negative_utility"`
+
+	RhoItems   int `json:"rho_items"`
+	DeltaItems int `json:"delta_items"`
+	EtaItems   int `json:"eta_items"`
+
+	RTWU       rtwuDistribution `json:"rtwu"`
+	TopItems   []itemRTWU       `json:"top_items"`
+	Thresholds []thresholdStats `json:"thresholds"`
+}
+
+// rtwuDistribution summarises the RTWU of the items.
+type rtwuDistribution struct {
+	Min    float64 `json:"min"`
+	P25    float64 `json:"p25"`
+	Median float64 `json:"median"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+}
+
+type itemRTWU struct {
+	Item  int     `json:"item"`
+	Name  string  `json:"name,omitempty"`
+	Class string  `json:"class"`
+	RTWU  float64 `json:"rtwu"`
+}
+
+// thresholdStats tells how many ρ and δ items survive the RTWU pruning for
+// --min-util-ratio ratio. Only those items can appear in a HUI, so the
+// count hints at the size of the search.
+type thresholdStats struct {
+	Ratio          float64 `json:"ratio"`
+	MinUtility     float64 `json:"min_util"`
+	SecondaryItems int     `json:"secondary_items"`
+}
+
+func runStats(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "dataset file in items:TU:utilities format (required)")
+	format := fs.String("format", "text", "report format: text or json")
+	top := fs.Int("top", 10, "number of items with the highest RTWU to list")
+	ratios := fs.String("ratios", "0.001,0.005,0.01,0.05,0.1", "comma-separated --min-util-ratio values to report the surviving items for")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *input == "" {
+		fmt.Fprintln(stderr, "stats: --input is required")
+		fs.Usage()
+		return exitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(stderr, "stats: unsupported format %q\n", *format)
+		return exitUsage
+	}
+	if *top < 0 {
+		fmt.Fprintln(stderr, "stats: --top must not be negative")
+		return exitUsage
+	}
+	var thresholdRatios []float64
+	for _, field := range strings.Split(*ratios, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		ratio, err := strconv.ParseFloat(field, 64)
+		if err != nil || ratio <= 0 || ratio > 1 {
+			fmt.Fprintf(stderr, "stats: invalid ratio %q, must be in (0, 1]\n", field)
+			return exitUsage
+		}
+		thresholdRatios = append(thresholdRatios, ratio)
+	}
+
+	data, err := dataset.ReadFile(*input, dataset.Lenient)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading transactions:", err)
+		return exitError
+	}
+
+	stats := computeDatasetStats(data.Transactions, data.ItemNames, *top, thresholdRatios)
+	stats.Dataset = *input
+	stats.Skipped = data.Report.Skipped
+
+	if *format == "json" {
+		out, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fmt.Fprintln(stderr, "Error writing stats:", err)
+			return exitError
+		}
+		fmt.Fprintf(stdout, "%s\n", out)
+		return exitOK
+	}
+	printDatasetStats(stdout, stats)
+	return exitOK
+}
+
+func computeDatasetStats(transactions []*models.Transaction, names map[int]string, top int, ratios []float64) datasetStats {
+	var stats datasetStats
+	stats.Transactions = len(transactions)
+
+	distinctItems := make(map[int]bool)
+	totalItems := 0
+	for _, transaction := range transactions {
+		for _, item := range transaction.Items {
+			distinctItems[item] = true
+		}
+		totalItems += len(transaction.Items)
+		stats.MaxLength = max(stats.MaxLength, len(transaction.Items))
+		for _, u := range transaction.Utilities {
+			stats.TotalUtility += u
+			if u > 0 {
+				stats.PositiveUtility += u
+			} else {
+				stats.NegativeUtility += u
+			}
+		}
+	}
+	stats.DistinctItems = len(distinctItems)
+	if len(transactions) > 0 {
+		stats.AverageLength = float64(totalItems) / float64(len(transactions))
+	}
+	if len(distinctItems) > 0 {
+		stats.Density = stats.AverageLength / float64(len(distinctItems))
+	}
+
+	// Phân lớp và RTWU được tính đúng như khi chạy EMHUN
+	e := algorithms.NewEMHUN(transactions, 0)
+	e.ClassifyItems()
+	stats.RhoItems, stats.DeltaItems, stats.EtaItems = len(e.Rho), len(e.Delta), len(e.Eta)
+	utility.CalculateRTWUForAllItems(transactions, e.Rho, e.Delta, e.Eta, e.UtilityArray)
+
+	class := func(item int) string {
+		switch {
+		case e.Rho[item]:
+			return "rho"
+		case e.Delta[item]:
+			return "delta"
+		case e.Eta[item]:
+			return "eta"
+		}
+		return "zero"
+	}
+	var items []itemRTWU
+	for item := range distinctItems {
+		items = append(items, itemRTWU{Item: item, Name: names[item], Class: class(item), RTWU: e.UtilityArray.GetRTWU(item)})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].RTWU != items[j].RTWU {
+			return items[i].RTWU > items[j].RTWU
+		}
+		return items[i].Item < items[j].Item
+	})
+	stats.TopItems = items[:min(top, len(items))]
+
+	if len(items) > 0 {
+		rtwus := make([]float64, len(items))
+		sum := 0.0
+		for i, item := range items {
+			rtwus[i] = item.RTWU
+			sum += item.RTWU
+		}
+		slices.Sort(rtwus)
+		// Phân vị theo thứ hạng gần nhất
+		quantile := func(q float64) float64 {
+			return rtwus[max(0, int(math.Ceil(q*float64(len(rtwus))))-1)]
+		}
+		stats.RTWU = rtwuDistribution{
+			Min:    rtwus[0],
+			P25:    quantile(0.25),
+			Median: quantile(0.5),
+			P75:    quantile(0.75),
+			P90:    quantile(0.9),
+			P99:    quantile(0.99),
+			Max:    rtwus[len(rtwus)-1],
+			Mean:   sum / float64(len(rtwus)),
+		}
+	}
+
+	for _, ratio := range ratios {
+		threshold := thresholdStats{Ratio: ratio, MinUtility: ratio * stats.PositiveUtility}
+		for _, item := range items {
+			if (e.Rho[item.Item] || e.Delta[item.Item]) && item.RTWU >= threshold.MinUtility {
+				threshold.SecondaryItems++
+			}
+		}
+		stats.Thresholds = append(stats.Thresholds, threshold)
+	}
+	return stats
+}
+
+func printDatasetStats(w io.Writer, stats datasetStats) {
+	fmt.Fprintf(w, "Dataset:            %s\n", stats.Dataset)
+	fmt.Fprintf(w, "Transactions:       %d\n", stats.Transactions)
+	if stats.Skipped > 0 {
+		fmt.Fprintf(w, "Skipped lines:      %d\n", stats.Skipped)
+	}
+	fmt.Fprintf(w, "Distinct items:     %d\n", stats.DistinctItems)
+	fmt.Fprintf(w, "Average length:     %.2f\n", stats.AverageLength)
+	fmt.Fprintf(w, "Max length:         %d\n", stats.MaxLength)
+	fmt.Fprintf(w, "Density:            %.4f\n", stats.Density)
+	fmt.Fprintf(w, "Total utility:      %.2f\n", stats.TotalUtility)
+	fmt.Fprintf(w, "Positive utility:   %.2f\n", stats.PositiveUtility)
+	fmt.Fprintf(w, "Negative utility:   %.2f\n", stats.NegativeUtility)
+	fmt.Fprintf(w, "Items ρ/δ/η:        %d / %d / %d\n", stats.RhoItems, stats.DeltaItems, stats.EtaItems)
+
+	fmt.Fprintln(w, "\nRTWU of the items:")
+	r := stats.RTWU
+	fmt.Fprintf(w, "  min %.2f  p25 %.2f  median %.2f  p75 %.2f  p90 %.2f  p99 %.2f  max %.2f  mean %.2f\n",
+		r.Min, r.P25, r.Median, r.P75, r.P90, r.P99, r.Max, r.Mean)
+
+	if len(stats.TopItems) > 0 {
+		fmt.Fprintf(w, "\nTop %d items by RTWU:\n", len(stats.TopItems))
+		for _, item := range stats.TopItems {
+			label := strconv.Itoa(item.Item)
+			if item.Name != "" {
+				label += " (" + item.Name + ")"
+			}
+			fmt.Fprintf(w, "  %-20s %-6s %.2f\n", label, item.Class, item.RTWU)
+		}
+	}
+
+	if len(stats.Thresholds) > 0 {
+		fmt.Fprintln(w, "\nItems ρ/δ passing the RTWU pruning:")
+		fmt.Fprintf(w, "  %-10s %-16s %s\n", "ratio", "min-util", "items")
+		for _, threshold := range stats.Thresholds {
+			fmt.Fprintf(w, "  %-10g %-16.2f %d\n", threshold.Ratio, threshold.MinUtility, threshold.SecondaryItems)
+		}
+	}
+}
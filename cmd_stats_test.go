@@ -0,0 +1,39 @@
+package main
+
+import (
+	"emhun/models"
+	"testing"
+)
+
+// TestDatasetStatsSparseItems computes the stats of a dataset whose item ids
+// are negative or far beyond the number of items.
+func TestDatasetStatsSparseItems(t *testing.T) {
+	const big = 3000000000
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, -3}, []float64{2, 3}, 5),
+		models.NewTransaction([]int{big, -3}, []float64{-1, 4}, 3),
+		models.NewTransaction([]int{1}, []float64{-2}, -2),
+	}
+	stats := computeDatasetStats(transactions, map[int]string{-3: "minus three"}, 10, []float64{0.5})
+
+	if stats.DistinctItems != 3 || stats.RhoItems != 1 || stats.DeltaItems != 1 || stats.EtaItems != 1 {
+		t.Errorf("stats %+v, want 3 items, one of each class", stats)
+	}
+	want := []itemRTWU{
+		{Item: -3, Name: "minus three", Class: "rho", RTWU: 9},
+		{Item: 1, Class: "delta", RTWU: 5},
+		{Item: big, Class: "eta", RTWU: 4},
+	}
+	if len(stats.TopItems) != len(want) {
+		t.Fatalf("top items %+v, want %+v", stats.TopItems, want)
+	}
+	for i, item := range stats.TopItems {
+		if item != want[i] {
+			t.Errorf("top item %d is %+v, want %+v", i, item, want[i])
+		}
+	}
+	// Ngưỡng 0.5 × 9: chỉ item -3 (ρ) và item 1 (δ) được tính
+	if got := stats.Thresholds[0].SecondaryItems; got != 2 {
+		t.Errorf("%d secondary items at ratio 0.5, want 2", got)
+	}
+}
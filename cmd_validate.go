@@ -0,0 +1,39 @@
+package main
+
+import (
+	"emhun/dataset"
+	"flag"
+	"fmt"
+	"io"
+)
+
+func runValidate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	input := fs.String("input", "", "dataset file in items:TU:utilities format (required)")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *input == "" {
+		fmt.Fprintln(stderr, "validate: --input is required")
+		fs.Usage()
+		return exitUsage
+	}
+
+	data, err := dataset.ReadFile(*input, dataset.Lenient)
+	if err != nil {
+		fmt.Fprintln(stderr, "Error reading dataset:", err)
+		return exitError
+	}
+
+	report := data.Report
+	for _, issue := range report.Issues {
+		fmt.Fprintln(stdout, issue)
+	}
+	fmt.Fprintf(stdout, "%d lines checked, %d transactions, %d issues, %d lines skipped\n",
+		report.Lines, report.Transactions, len(report.Issues), report.Skipped)
+	if !report.OK() {
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,290 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"emhun/models"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// The binary format stores a dataset column by column:
+//
+//	magic          "EMHUNDB1"
+//	counts         uvarint items, transactions, entries (Σ transaction lengths), names
+//	dictionary     items × varint original item id, indexed by dense id
+//	names          names × (uvarint dense id, uvarint length, bytes)
+//	lengths        transactions × uvarint
+//	items          entries × uvarint dense id
+//	utilities      entries × float64, little endian
+//	TU             transactions × float64, little endian
+//
+// Floats are stored bit for bit, so a dataset read back is identical to the
+// one written.
+var binaryMagic = []byte("EMHUNDB1")
+
+// ErrCorrupt is returned for binary datasets that are truncated or malformed.
+var ErrCorrupt = errors.New("corrupt binary dataset")
+
+// WriteBinary writes d in the binary format.
+func WriteBinary(w io.Writer, d *Dataset) error {
+	ids := make(map[int]int)
+	var dictionary []int
+	entries := 0
+	for _, transaction := range d.Transactions {
+		entries += len(transaction.Items)
+		for _, item := range transaction.Items {
+			if _, ok := ids[item]; !ok {
+				ids[item] = len(dictionary)
+				dictionary = append(dictionary, item)
+			}
+		}
+	}
+	var names []int
+	for _, item := range dictionary {
+		if _, ok := d.ItemNames[item]; ok {
+			names = append(names, item)
+		}
+	}
+
+	writer := bufio.NewWriter(w)
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) { writer.Write(buf[:binary.PutUvarint(buf, v)]) }
+	putVarint := func(v int64) { writer.Write(buf[:binary.PutVarint(buf, v)]) }
+	putFloat := func(f float64) {
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(f))
+		writer.Write(buf[:8])
+	}
+
+	writer.Write(binaryMagic)
+	putUvarint(uint64(len(dictionary)))
+	putUvarint(uint64(len(d.Transactions)))
+	putUvarint(uint64(entries))
+	putUvarint(uint64(len(names)))
+	for _, item := range dictionary {
+		putVarint(int64(item))
+	}
+	for _, item := range names {
+		putUvarint(uint64(ids[item]))
+		putUvarint(uint64(len(d.ItemNames[item])))
+		writer.WriteString(d.ItemNames[item])
+	}
+	for _, transaction := range d.Transactions {
+		putUvarint(uint64(len(transaction.Items)))
+	}
+	for _, transaction := range d.Transactions {
+		for _, item := range transaction.Items {
+			putUvarint(uint64(ids[item]))
+		}
+	}
+	for _, transaction := range d.Transactions {
+		for _, utility := range transaction.Utilities {
+			putFloat(utility)
+		}
+	}
+	for _, transaction := range d.Transactions {
+		putFloat(transaction.TransactionUtility)
+	}
+	return writer.Flush()
+}
+
+// WriteBinaryFile writes d to path in the binary format.
+func WriteBinaryFile(path string, d *Dataset) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := WriteBinary(file, d); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// IsBinaryFile reports whether the file at path starts with the magic of the
+// binary format.
+func IsBinaryFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false, nil
+	}
+	return bytes.Equal(magic, binaryMagic), nil
+}
+
+// ReadBinaryFile reads a binary dataset, memory-mapping the file where the
+// platform supports it. The result is the same as reading the text file the
+// cache was built from.
+func ReadBinaryFile(path string) (*Dataset, error) {
+	data, unmap, err := mapFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unmap()
+
+	d, err := DecodeBinary(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return d, nil
+}
+
+// DecodeBinary decodes a dataset in the binary format. The returned dataset
+// does not refer to data.
+func DecodeBinary(data []byte) (*Dataset, error) {
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return nil, ErrCorrupt
+	}
+	dec := &binaryDecoder{data: data, pos: len(binaryMagic)}
+
+	itemCount := dec.count()
+	transactionCount := dec.count()
+	entryCount := dec.count()
+	nameCount := dec.count()
+	// Every entry takes at least one byte for its item and eight for its
+	// utility, which bounds the allocations below by the input size.
+	if dec.err != nil || itemCount+nameCount+transactionCount > len(data) || entryCount > len(data)/9 {
+		return nil, ErrCorrupt
+	}
+
+	dictionary := make([]int, itemCount)
+	for i := range dictionary {
+		dictionary[i] = int(dec.varint())
+	}
+	names := make(map[int]string, nameCount)
+	for range nameCount {
+		id := dec.index(itemCount)
+		length := dec.count()
+		name := dec.bytes(length)
+		if dec.err == nil {
+			names[dictionary[id]] = string(name)
+		}
+	}
+
+	lengths := make([]int, transactionCount)
+	total := 0
+	for i := range lengths {
+		lengths[i] = dec.count()
+		total += lengths[i]
+	}
+	if dec.err != nil || total != entryCount {
+		return nil, ErrCorrupt
+	}
+
+	// One backing array for all transactions; the full slice expressions
+	// below keep an append on one transaction from overwriting the next.
+	items := make([]int, entryCount)
+	for i := range items {
+		id := dec.index(itemCount)
+		if dec.err != nil {
+			return nil, ErrCorrupt
+		}
+		items[i] = dictionary[id]
+	}
+	utilities := make([]float64, entryCount)
+	for i := range utilities {
+		utilities[i] = dec.float()
+	}
+
+	transactions := make([]*models.Transaction, transactionCount)
+	start := 0
+	for i, length := range lengths {
+		end := start + length
+		transactions[i] = models.NewTransaction(items[start:end:end], utilities[start:end:end], dec.float())
+		start = end
+	}
+	if dec.err != nil || dec.pos != len(data) {
+		return nil, ErrCorrupt
+	}
+
+	return &Dataset{
+		Transactions: transactions,
+		ItemNames:    names,
+		Report:       &Report{Lines: transactionCount, Transactions: transactionCount},
+	}, nil
+}
+
+// binaryDecoder reads values from data and remembers the first error, so
+// that callers can check once after a sequence of reads.
+type binaryDecoder struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		d.err = ErrCorrupt
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+// count reads a non-negative size that must fit in the input.
+func (d *binaryDecoder) count() int {
+	v := d.uvarint()
+	if v > uint64(len(d.data)) {
+		d.err = ErrCorrupt
+		return 0
+	}
+	return int(v)
+}
+
+// index reads a dense id below limit.
+func (d *binaryDecoder) index(limit int) int {
+	v := d.uvarint()
+	if v >= uint64(limit) {
+		d.err = ErrCorrupt
+		return 0
+	}
+	return int(v)
+}
+
+func (d *binaryDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data)-d.pos {
+		d.err = ErrCorrupt
+		return nil
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b
+}
+
+func (d *binaryDecoder) float() float64 {
+	b := d.bytes(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
@@ -0,0 +1,74 @@
+package dataset
+
+import (
+	"bytes"
+	"maps"
+	"math/rand"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for range 100 {
+		want := randomDataset(r)
+		var buf bytes.Buffer
+		if err := WriteBinary(&buf, want); err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecodeBinary(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalTransactions(got.Transactions, want.Transactions) {
+			t.Fatalf("decoded\n%v\nwant\n%v", got.Transactions, want.Transactions)
+		}
+		// Chỉ tên của các item xuất hiện trong dữ liệu được lưu
+		for item, name := range want.ItemNames {
+			if got.ItemNames[item] != name && hasItem(want, item) {
+				t.Fatalf("item %d named %q, want %q", item, got.ItemNames[item], name)
+			}
+		}
+	}
+}
+
+func hasItem(d *Dataset, item int) bool {
+	for _, transaction := range d.Transactions {
+		for _, i := range transaction.Items {
+			if i == item {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func FuzzDecodeBinary(f *testing.F) {
+	r := rand.New(rand.NewSource(4))
+	for range 5 {
+		var buf bytes.Buffer
+		if err := WriteBinary(&buf, randomDataset(r)); err != nil {
+			f.Fatal(err)
+		}
+		f.Add(buf.Bytes())
+	}
+	f.Add(binaryMagic)
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d, err := DecodeBinary(data)
+		if err != nil {
+			return
+		}
+
+		// Dữ liệu giải mã được phải mã hóa lại rồi giải mã ra đúng như cũ
+		var buf bytes.Buffer
+		if err := WriteBinary(&buf, d); err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeBinary(buf.Bytes())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalTransactions(again.Transactions, d.Transactions) || !maps.Equal(again.ItemNames, d.ItemNames) {
+			t.Fatalf("re-encoded dataset differs")
+		}
+	})
+}
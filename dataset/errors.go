@@ -0,0 +1,61 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrFormat is returned for lines that are not items:TU:utilities.
+	ErrFormat = errors.New("invalid line format")
+	// ErrItem is returned for an item that is not an integer.
+	ErrItem = errors.New("invalid item")
+	// ErrUtility is returned for a utility that is not a number.
+	ErrUtility = errors.New("invalid utility")
+	// ErrTransactionUtility is returned for a TU that is not a number.
+	ErrTransactionUtility = errors.New("invalid transaction utility")
+	// ErrCountMismatch is returned when a line has more items than
+	// utilities or the other way round.
+	ErrCountMismatch = errors.New("item and utility counts differ")
+	// ErrEmptyTransaction is returned for a line without items.
+	ErrEmptyTransaction = errors.New("transaction has no items")
+	// ErrTUMismatch is returned when the declared TU is neither the sum of
+	// the utilities nor the sum of the positive ones.
+	ErrTUMismatch = errors.New("declared transaction utility does not match the utilities")
+	// ErrDuplicateItem is returned when an item appears twice in a line,
+	// or twice in a profit or threshold table.
+	ErrDuplicateItem = errors.New("duplicate item")
+)
+
+// ParseError is a problem found on one line of a dataset.
+type ParseError struct {
+	// Path is the file the line comes from, if known.
+	Path string
+	Line int
+	Err  error
+	// Detail describes the offending value, e.g. the text that is not a
+	// number.
+	Detail string
+}
+
+func (e *ParseError) Error() string {
+	position := fmt.Sprintf("line %d", e.Line)
+	if e.Path != "" {
+		position = fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
+	if e.Detail == "" {
+		return fmt.Sprintf("%s: %v", position, e.Err)
+	}
+	return fmt.Sprintf("%s: %v: %s", position, e.Err, e.Detail)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// skipsLine reports whether err makes a line unusable. The other problems
+// (ErrTUMismatch, ErrDuplicateItem) are reported but the transaction is kept:
+// the TU is recomputed by the algorithm and duplicate items are merged.
+func skipsLine(err error) bool {
+	return !errors.Is(err, ErrTUMismatch) && !errors.Is(err, ErrDuplicateItem)
+}
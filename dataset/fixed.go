@@ -0,0 +1,180 @@
+package dataset
+
+import (
+	"emhun/utility"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Exact mode reads every number as an integer scaled by 10^decimals. The
+// scaled values are stored in the usual float64 fields, and the readers
+// reject datasets whose absolute utilities add up to more than MaxExactTotal,
+// the largest total for which float64 keeps every sum exact; see
+// utility.CheckExact.
+
+// MaxExactTotal is the largest sum of absolute scaled utilities accepted in
+// exact mode. It is a float64 limit, not an int64 one.
+const MaxExactTotal = utility.MaxExactTotal
+
+var (
+	// ErrNumber is returned by ParseFixed for text that is not a decimal
+	// number.
+	ErrNumber = errors.New("not a decimal number")
+	// ErrPrecision is returned for a number with more decimal places than
+	// exact mode was configured with.
+	ErrPrecision = errors.New("too many decimal places")
+	// ErrOverflow is returned when a scaled value or the total of the
+	// dataset does not fit.
+	ErrOverflow = errors.New("value out of range for exact mode")
+)
+
+// ParseFixed parses a decimal number such as "-152.46" as an integer scaled by
+// 10^decimals. Numbers with more significant decimal places are rejected.
+func ParseFixed(s string, decimals int) (int64, error) {
+	return parseFixed(s, decimals, false)
+}
+
+// ParseFixedCeil is ParseFixed rounding extra decimal places up instead of
+// rejecting them. It is used for thresholds: with integer utilities,
+// u >= t holds exactly when u >= ceil(t).
+func ParseFixedCeil(s string, decimals int) (int64, error) {
+	return parseFixed(s, decimals, true)
+}
+
+func parseFixed(s string, decimals int, ceil bool) (int64, error) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return 0, ErrNumber
+	}
+
+	var v uint64
+	push := func(digit byte) error {
+		if v > (math.MaxInt64-uint64(digit-'0'))/10 {
+			return ErrOverflow
+		}
+		v = v*10 + uint64(digit-'0')
+		return nil
+	}
+	for i := 0; i < len(intPart); i++ {
+		if err := push(intPart[i]); err != nil {
+			return 0, err
+		}
+	}
+	for i := 0; i < decimals; i++ {
+		digit := byte('0')
+		if i < len(fracPart) {
+			digit = fracPart[i]
+		}
+		if err := push(digit); err != nil {
+			return 0, err
+		}
+	}
+
+	if len(fracPart) > decimals && strings.Trim(fracPart[decimals:], "0") != "" {
+		if !ceil {
+			return 0, ErrPrecision
+		}
+		// Dropping digits rounds toward zero, which is already up for
+		// negative numbers.
+		if !negative {
+			if v == math.MaxInt64 {
+				return 0, ErrOverflow
+			}
+			v++
+		}
+	}
+	if negative {
+		return -int64(v), nil
+	}
+	return int64(v), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FixedRatioCeil returns ceil(ratio × total) for a decimal ratio such as
+// "0.015" and a scaled total, computed exactly.
+func FixedRatioCeil(ratio string, total int64) (int64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(ratio))
+	if !ok {
+		return 0, ErrNumber
+	}
+	r.Mul(r, new(big.Rat).SetInt64(total))
+	q, m := new(big.Int).DivMod(r.Num(), r.Denom(), new(big.Int))
+	if m.Sign() != 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
+
+// exactTotal accumulates the absolute scaled utilities of a dataset and
+// reports when they exceed MaxExactTotal.
+type exactTotal struct {
+	sum uint64
+}
+
+func (t *exactTotal) add(utilities []float64) bool {
+	for _, u := range utilities {
+		t.sum += uint64(math.Abs(u))
+		if t.sum > MaxExactTotal {
+			return false
+		}
+	}
+	return true
+}
+
+// ScaleExact converts a dataset with float utilities, e.g. from a binary cache
+// or a profit table, to exact mode. Every value must be an integer once
+// scaled, up to the error of its float64 representation.
+func (d *Dataset) ScaleExact(decimals int) error {
+	scale := math.Pow10(decimals)
+	convert := func(f float64) (float64, error) {
+		scaled := f * scale
+		rounded := math.Round(scaled)
+		if math.Abs(rounded) > MaxExactTotal {
+			return 0, ErrOverflow
+		}
+		if math.Abs(scaled-rounded) > 1e-6*math.Max(1, math.Abs(scaled)) {
+			return 0, ErrPrecision
+		}
+		return rounded, nil
+	}
+
+	var total exactTotal
+	for _, transaction := range d.Transactions {
+		for i, u := range transaction.Utilities {
+			scaled, err := convert(u)
+			if err != nil {
+				return err
+			}
+			transaction.Utilities[i] = scaled
+		}
+		tu, err := convert(transaction.TransactionUtility)
+		if err != nil {
+			return err
+		}
+		transaction.TransactionUtility = tu
+		if !total.add(transaction.Utilities) {
+			return ErrOverflow
+		}
+	}
+	d.Exact, d.Decimals = true, decimals
+	return nil
+}
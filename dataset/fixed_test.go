@@ -0,0 +1,28 @@
+package dataset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestReaderExactLimit reads a dataset whose utilities add up to exactly
+// MaxExactTotal, then one with a unit more.
+func TestReaderExactLimit(t *testing.T) {
+	const limit = "9007199254740992"
+	for _, tt := range []struct {
+		input string
+		err   error
+	}{
+		{"1:" + limit + ":" + limit + "\n", nil},
+		{"1:4503599627370496:4503599627370496\n2:-4503599627370496:-4503599627370496\n", nil},
+		{"1:" + limit + ":" + limit + "\n2:1:1\n", ErrOverflow},
+		{"1:1:9007199254740993\n", ErrOverflow},
+	} {
+		reader := NewReader(strings.NewReader(tt.input), Strict)
+		reader.SetExact(0)
+		if _, err := reader.ReadAll(); !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+			t.Errorf("%q: error %v, want %v", tt.input, err, tt.err)
+		}
+	}
+}
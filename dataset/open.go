@@ -0,0 +1,62 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+)
+
+// Open opens a dataset file for reading. Files compressed with gzip or bzip2
+// are decompressed on the fly; compression is detected from the magic bytes,
+// or from a .gz or .bz2 extension.
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(file)
+	magic, _ := buffered.Peek(len(bzip2Magic))
+	ext := strings.ToLower(filepath.Ext(path))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic) || ext == ".gz":
+		decompressed, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return &readCloser{Reader: decompressed, closers: []io.Closer{decompressed, file}}, nil
+	case bytes.HasPrefix(magic, bzip2Magic) || ext == ".bz2":
+		return &readCloser{Reader: bzip2.NewReader(buffered), closers: []io.Closer{file}}, nil
+	default:
+		return &readCloser{Reader: buffered, closers: []io.Closer{file}}, nil
+	}
+}
+
+// readCloser closes the decompressor and the file in order.
+type readCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *readCloser) Close() error {
+	var first error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
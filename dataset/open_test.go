@@ -0,0 +1,82 @@
+package dataset
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestOpenCompressed reads table3 plain, gzip and bzip2 compressed, with the
+// compression told by the extension and only by the magic bytes.
+func TestOpenCompressed(t *testing.T) {
+	plain, err := os.ReadFile("../data/table3.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(plain)
+	w.Close()
+	bz2, err := os.ReadFile("testdata/table3.txt.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ReadFile("../data/table3.txt", Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"table3.txt", plain},
+		{"table3.txt.gz", gz.Bytes()},
+		{"table3.GZ", gz.Bytes()},
+		{"gzip.txt", gz.Bytes()},
+		{"table3.txt.bz2", bz2},
+		{"bzip2.txt", bz2},
+		{"bzip2", bz2},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadFile(path, Strict)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Transactions, want.Transactions) {
+				t.Errorf("read\n%v\nwant\n%v", got.Transactions, want.Transactions)
+			}
+		})
+	}
+}
+
+// TestOpenCorrupt checks that a file claiming to be compressed but holding
+// something else fails instead of being read as text.
+func TestOpenCorrupt(t *testing.T) {
+	dir := t.TempDir()
+	for _, tt := range []struct {
+		name string
+		data []byte
+	}{
+		{"plain.gz", []byte("1 2:3:1 2\n")},
+		{"truncated.gz", []byte{0x1f, 0x8b, 0x08}},
+		{"plain.bz2", []byte("1 2:3:1 2\n")},
+	} {
+		path := filepath.Join(dir, tt.name)
+		if err := os.WriteFile(path, tt.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadFile(path, Lenient); err == nil {
+			t.Errorf("%s: read without error", tt.name)
+		}
+	}
+}
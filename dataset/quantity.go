@@ -0,0 +1,204 @@
+package dataset
+
+import (
+	"bufio"
+	"emhun/models"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrQuantity is returned for a quantity that is not a number.
+	ErrQuantity = errors.New("invalid quantity")
+	// ErrProfit is returned for a profit that is not a number.
+	ErrProfit = errors.New("invalid profit")
+	// ErrMissingProfit is returned when an item has no unit profit.
+	ErrMissingProfit = errors.New("no unit profit for item")
+)
+
+// QuantityTransaction is a transaction as bought: items and the quantity of
+// each, without profits.
+type QuantityTransaction struct {
+	Items      []int
+	Quantities []float64
+}
+
+// QuantityDatabase holds the transactions of a quantity file. It is kept
+// apart from the profits so that the same data can be mined with different
+// profit tables.
+type QuantityDatabase struct {
+	Transactions []QuantityTransaction
+	Report       *Report
+}
+
+// ProfitTable maps an item to its unit profit, which may be negative.
+type ProfitTable map[int]float64
+
+// ReadQuantityFile reads a file with one transaction per line, written as
+// space separated item:quantity pairs. Blank, comment and metadata lines are
+// skipped as in the utility format. The file may be compressed.
+func ReadQuantityFile(path string, mode Mode) (*QuantityDatabase, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	db := &QuantityDatabase{Report: &Report{}}
+	err = readLines(file, path, func(line int, text string) *ParseError {
+		db.Report.Lines++
+		transaction, issue := parseQuantityLine(path, line, text)
+		if issue != nil {
+			if mode == Strict {
+				return issue
+			}
+			db.Report.add(issue)
+			if skipsLine(issue.Err) {
+				return nil
+			}
+		}
+		if transaction != nil {
+			db.Report.Transactions++
+			db.Transactions = append(db.Transactions, *transaction)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func parseQuantityLine(path string, line int, text string) (*QuantityTransaction, *ParseError) {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text[:1], "#%@") {
+		return nil, nil
+	}
+
+	fields := strings.Fields(text)
+	transaction := &QuantityTransaction{
+		Items:      make([]int, 0, len(fields)),
+		Quantities: make([]float64, 0, len(fields)),
+	}
+	seen := make(map[int]bool, len(fields))
+	var duplicate *ParseError
+	for _, field := range fields {
+		itemText, quantityText, found := strings.Cut(field, ":")
+		if !found {
+			return nil, &ParseError{Path: path, Line: line, Err: ErrFormat, Detail: field}
+		}
+		item, err := strconv.Atoi(itemText)
+		if err != nil {
+			return nil, &ParseError{Path: path, Line: line, Err: ErrItem, Detail: itemText}
+		}
+		quantity, err := strconv.ParseFloat(quantityText, 64)
+		if err != nil {
+			return nil, &ParseError{Path: path, Line: line, Err: ErrQuantity, Detail: quantityText}
+		}
+		if seen[item] && duplicate == nil {
+			duplicate = &ParseError{Path: path, Line: line, Err: ErrDuplicateItem, Detail: itemText}
+		}
+		seen[item] = true
+		transaction.Items = append(transaction.Items, item)
+		transaction.Quantities = append(transaction.Quantities, quantity)
+	}
+	if duplicate != nil {
+		return transaction, duplicate
+	}
+	return transaction, nil
+}
+
+// ReadProfitTable reads a unit-profit table with one "item profit" pair per
+// line. Blank lines and lines starting with # or % are skipped.
+func ReadProfitTable(path string) (ProfitTable, error) {
+	return readItemTable(path, ErrProfit)
+}
+
+// readItemTable reads "item value" pairs; errValue reports a bad value and
+// an item listed twice is an ErrDuplicateItem.
+func readItemTable(path string, errValue error) (map[int]float64, error) {
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[int]float64)
+	err = readLines(file, path, func(line int, text string) *ParseError {
+		text = strings.TrimSpace(text)
+		if text == "" || strings.ContainsAny(text[:1], "#%") {
+			return nil
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return &ParseError{Path: path, Line: line, Err: ErrFormat, Detail: text}
+		}
+		item, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return &ParseError{Path: path, Line: line, Err: ErrItem, Detail: fields[0]}
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return &ParseError{Path: path, Line: line, Err: errValue, Detail: fields[1]}
+		}
+		if _, ok := values[item]; ok {
+			return &ParseError{Path: path, Line: line, Err: ErrDuplicateItem, Detail: fields[0]}
+		}
+		values[item] = value
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Apply builds utility transactions with utility = quantity × unit profit.
+// The transactions are new on every call, so the database can be mined again
+// with another table. The TU of a transaction is the sum of its positive
+// utilities.
+func (db *QuantityDatabase) Apply(profits ProfitTable) ([]*models.Transaction, error) {
+	transactions := make([]*models.Transaction, 0, len(db.Transactions))
+	for _, qt := range db.Transactions {
+		items := make([]int, len(qt.Items))
+		utilities := make([]float64, len(qt.Items))
+		tu := 0.0
+		for i, item := range qt.Items {
+			profit, ok := profits[item]
+			if !ok {
+				return nil, fmt.Errorf("%w %d", ErrMissingProfit, item)
+			}
+			items[i] = item
+			utilities[i] = qt.Quantities[i] * profit
+			if utilities[i] > 0 {
+				tu += utilities[i]
+			}
+		}
+		transactions = append(transactions, models.NewTransaction(items, utilities, tu))
+	}
+	return transactions, nil
+}
+
+// readLines calls fn for every line of r with its 1-based number and stops at
+// the first error fn returns.
+func readLines(r io.Reader, path string, fn func(line int, text string) *ParseError) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if text == "" && err == io.EOF {
+			return nil
+		}
+		if issue := fn(line, text); issue != nil {
+			return issue
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package dataset
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeTemp writes text to a file named name in a temporary directory.
+func writeTemp(t *testing.T, name, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQuantityApply(t *testing.T) {
+	db, err := ReadQuantityFile(writeTemp(t, "quantities.txt", "# sales\n1:2 2:3\n\n2:1.5 3:4\n"), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		profits   ProfitTable
+		utilities [][]float64
+		tus       []float64
+	}{
+		{"positive", ProfitTable{1: 5, 2: 1, 3: 0.5}, [][]float64{{10, 3}, {1.5, 2}}, []float64{13, 3.5}},
+		// Item 2 bán lỗ: TU chỉ cộng các utility dương
+		{"loss leader", ProfitTable{1: 5, 2: -2, 3: 0.5}, [][]float64{{10, -6}, {-3, 2}}, []float64{10, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions, err := db.Apply(tt.profits)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(transactions) != len(tt.utilities) {
+				t.Fatalf("%d transactions, want %d", len(transactions), len(tt.utilities))
+			}
+			for i, transaction := range transactions {
+				if !slices.Equal(transaction.Utilities, tt.utilities[i]) || transaction.TransactionUtility != tt.tus[i] {
+					t.Errorf("transaction %d is %v, want utilities %v and TU %g", i, transaction, tt.utilities[i], tt.tus[i])
+				}
+			}
+		})
+	}
+
+	// Áp dụng lại bảng khác không được sửa kết quả lần trước
+	first, _ := db.Apply(tests[0].profits)
+	db.Apply(tests[1].profits)
+	if !slices.Equal(first[0].Utilities, tests[0].utilities[0]) {
+		t.Errorf("applying another table changed %v", first[0])
+	}
+
+	if _, err := db.Apply(ProfitTable{1: 5, 2: 1}); !errors.Is(err, ErrMissingProfit) {
+		t.Errorf("table without item 3: error %v, want %v", err, ErrMissingProfit)
+	}
+}
+
+func TestReadQuantityFileErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+		line  int
+	}{
+		{"pair", "1:2\n1 2:3\n", ErrFormat, 2},
+		{"item", "a:2\n", ErrItem, 1},
+		{"quantity", "1:2 2:x\n", ErrQuantity, 1},
+		{"duplicate item", "% comment\n1:2 1:3\n", ErrDuplicateItem, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadQuantityFile(writeTemp(t, "quantities.txt", tt.input), Strict)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, tt.err) || parseErr.Line != tt.line {
+				t.Errorf("error %v, want %v on line %d", err, tt.err, tt.line)
+			}
+		})
+	}
+
+	// Chế độ lenient bỏ dòng hỏng và giữ dòng có item lặp
+	db, err := ReadQuantityFile(writeTemp(t, "quantities.txt", "1:2\n1:x\n2:1 2:1\n@NAME\n"), Lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := db.Report
+	if len(db.Transactions) != 2 || report.Lines != 4 || report.Transactions != 2 || report.Skipped != 1 || len(report.Issues) != 2 {
+		t.Errorf("%d transactions, report %+v", len(db.Transactions), report)
+	}
+}
+
+func TestReadProfitTable(t *testing.T) {
+	profits, err := ReadProfitTable(writeTemp(t, "profits.txt", "# item profit\n1 5\n2 -2.5\n\n3 0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ProfitTable{1: 5, 2: -2.5, 3: 0}); len(profits) != len(want) || profits[1] != 5 || profits[2] != -2.5 || profits[3] != 0 {
+		t.Errorf("profits %v, want %v", profits, want)
+	}
+
+	for _, tt := range []struct {
+		input string
+		err   error
+		line  int
+	}{
+		{"1 5\n2\n", ErrFormat, 2},
+		{"1 5 6\n", ErrFormat, 1},
+		{"x 5\n", ErrItem, 1},
+		{"1 5\n2 y\n", ErrProfit, 2},
+		{"1 5\n1 6\n", ErrDuplicateItem, 2},
+	} {
+		_, err := ReadProfitTable(writeTemp(t, "profits.txt", tt.input))
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || !errors.Is(err, tt.err) || parseErr.Line != tt.line {
+			t.Errorf("%q: error %v, want %v on line %d", tt.input, err, tt.err, tt.line)
+		}
+	}
+}
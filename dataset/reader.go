@@ -0,0 +1,334 @@
+// Package dataset reads transaction databases in the SPMF utility format:
+// one "items:TU:utilities" line per transaction, with optional comment (#, %)
+// and metadata (@) lines.
+package dataset
+
+import (
+	"bufio"
+	"emhun/models"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Mode selects how a Reader handles problems in the input.
+type Mode int
+
+const (
+	// Lenient skips unusable lines and records every problem in the Report.
+	Lenient Mode = iota
+	// Strict stops at the first problem and returns it as a *ParseError.
+	Strict
+)
+
+// tuTolerance is the relative difference allowed between the declared TU
+// and the sum of the utilities, to absorb rounding in the input.
+const tuTolerance = 1e-9
+
+// Reader parses transactions from an io.Reader one line at a time. Lines
+// have no length limit.
+type Reader struct {
+	Mode Mode
+
+	r         *bufio.Reader
+	path      string
+	line      int
+	itemNames map[int]string
+	report    Report
+
+	// Set by SetExact, see fixed.go.
+	exact    bool
+	decimals int
+	total    exactTotal
+}
+
+func NewReader(r io.Reader, mode Mode) *Reader {
+	return &Reader{
+		Mode:      mode,
+		r:         bufio.NewReader(r),
+		itemNames: make(map[int]string),
+	}
+}
+
+// SetExact switches the reader to exact mode: numbers are parsed as integers
+// scaled by 10^decimals, and reading fails with ErrOverflow once the absolute
+// utilities add up to more than MaxExactTotal.
+func (r *Reader) SetExact(decimals int) {
+	r.exact = true
+	r.decimals = decimals
+}
+
+// Read returns the next transaction, or io.EOF after the last one.
+func (r *Reader) Read() (*models.Transaction, error) {
+	for {
+		text, readErr := r.r.ReadString('\n')
+		if readErr != nil && readErr != io.EOF {
+			return nil, readErr
+		}
+		if text == "" && readErr == io.EOF {
+			return nil, io.EOF
+		}
+		r.line++
+		r.report.Lines++
+
+		transaction, issues := r.parseLine(text)
+		for _, issue := range issues {
+			if r.Mode == Strict {
+				return nil, issue
+			}
+			r.report.add(issue)
+		}
+		if transaction != nil {
+			if r.exact && !r.total.add(transaction.Utilities) {
+				return nil, r.errorf(ErrOverflow, "total utility exceeds 2^53 units")
+			}
+			r.report.Transactions++
+			return transaction, nil
+		}
+		if readErr == io.EOF {
+			return nil, io.EOF
+		}
+	}
+}
+
+// ReadAll reads the remaining transactions.
+func (r *Reader) ReadAll() ([]*models.Transaction, error) {
+	var transactions []*models.Transaction
+	for {
+		transaction, err := r.Read()
+		if err == io.EOF {
+			return transactions, nil
+		}
+		if err != nil {
+			return transactions, err
+		}
+		transactions = append(transactions, transaction)
+	}
+}
+
+// Report returns the problems found so far.
+func (r *Reader) Report() *Report {
+	return &r.report
+}
+
+// ItemNames returns the names declared by "@ITEM=id=name" lines so far.
+func (r *Reader) ItemNames() map[int]string {
+	return r.itemNames
+}
+
+// parseLine returns the transaction on a line, or nil for comments, metadata
+// and unusable lines, along with the problems found.
+func (r *Reader) parseLine(text string) (*models.Transaction, []*ParseError) {
+	line := strings.TrimSpace(text)
+	if line == "" || line[0] == '#' || line[0] == '%' {
+		return nil, nil
+	}
+	if line[0] == '@' {
+		if err := r.parseMetadata(line); err != nil {
+			return nil, []*ParseError{err}
+		}
+		return nil, nil
+	}
+
+	transaction, err := r.parseTransaction(line)
+	if err != nil {
+		return nil, []*ParseError{err}
+	}
+	return transaction, r.check(transaction)
+}
+
+// parseMetadata records the item name of an "@ITEM=id=name" line and ignores
+// other metadata.
+func (r *Reader) parseMetadata(line string) *ParseError {
+	rest, found := strings.CutPrefix(line, "@ITEM=")
+	if !found {
+		return nil
+	}
+	idText, name, found := strings.Cut(rest, "=")
+	if !found {
+		return r.errorf(ErrFormat, line)
+	}
+	id, err := strconv.Atoi(strings.TrimSpace(idText))
+	if err != nil {
+		return r.errorf(ErrItem, idText)
+	}
+	r.itemNames[id] = name
+	return nil
+}
+
+func (r *Reader) parseTransaction(line string) (*models.Transaction, *ParseError) {
+	parts := strings.Split(line, ":")
+	if len(parts) != 3 {
+		return nil, r.errorf(ErrFormat, line)
+	}
+
+	itemFields := strings.Fields(parts[0])
+	items := make([]int, 0, len(itemFields))
+	for _, field := range itemFields {
+		item, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, r.errorf(ErrItem, field)
+		}
+		items = append(items, item)
+	}
+
+	tuText := strings.TrimSpace(parts[1])
+	// TU chỉ dùng để kiểm tra, nên trong chế độ exact được làm tròn thay vì
+	// bị từ chối khi file ghi TU với sai số float (như table1.txt)
+	transactionUtility, err := strconv.ParseFloat(tuText, 64)
+	if err != nil {
+		return nil, r.errorf(ErrTransactionUtility, tuText)
+	}
+	if r.exact {
+		transactionUtility = math.Round(transactionUtility * math.Pow10(r.decimals))
+	}
+
+	utilityFields := strings.Fields(parts[2])
+	utilities := make([]float64, 0, len(utilityFields))
+	for _, field := range utilityFields {
+		utility, err := r.parseNumber(field)
+		if err != nil {
+			return nil, r.numberError(ErrUtility, err, field)
+		}
+		utilities = append(utilities, utility)
+	}
+
+	if len(items) != len(utilities) {
+		return nil, r.errorf(ErrCountMismatch, strconv.Itoa(len(items))+" items, "+strconv.Itoa(len(utilities))+" utilities")
+	}
+	if len(items) == 0 {
+		return nil, r.errorf(ErrEmptyTransaction, "")
+	}
+	return models.NewTransaction(items, utilities, transactionUtility), nil
+}
+
+// check returns the problems of a well-formed transaction that do not make
+// it unusable.
+func (r *Reader) check(transaction *models.Transaction) []*ParseError {
+	var issues []*ParseError
+
+	seen := make(map[int]bool, len(transaction.Items))
+	for _, item := range transaction.Items {
+		if seen[item] {
+			issues = append(issues, r.errorf(ErrDuplicateItem, strconv.Itoa(item)))
+			break
+		}
+		seen[item] = true
+	}
+
+	sum, positive := 0.0, 0.0
+	for _, utility := range transaction.Utilities {
+		sum += utility
+		if utility > 0 {
+			positive += utility
+		}
+	}
+	tu := transaction.TransactionUtility
+	if !closeTo(tu, sum) && !closeTo(tu, positive) {
+		issues = append(issues, r.errorf(ErrTUMismatch, "declared "+formatFloat(tu)+", sum "+formatFloat(sum)+", positive sum "+formatFloat(positive)))
+	}
+	return issues
+}
+
+// parseNumber parses a utility, as a scaled integer in exact mode.
+func (r *Reader) parseNumber(text string) (float64, error) {
+	if !r.exact {
+		return strconv.ParseFloat(text, 64)
+	}
+	v, err := ParseFixed(text, r.decimals)
+	if err != nil {
+		return 0, err
+	}
+	if v > MaxExactTotal || v < -MaxExactTotal {
+		return 0, ErrOverflow
+	}
+	return float64(v), nil
+}
+
+// numberError reports a number that could not be parsed. Precision and
+// overflow problems of exact mode are reported as such, anything else as
+// invalid.
+func (r *Reader) numberError(invalid, err error, text string) *ParseError {
+	if errors.Is(err, ErrPrecision) || errors.Is(err, ErrOverflow) {
+		return r.errorf(err, text)
+	}
+	return r.errorf(invalid, text)
+}
+
+func (r *Reader) errorf(err error, detail string) *ParseError {
+	return &ParseError{Path: r.path, Line: r.line, Err: err, Detail: detail}
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) <= tuTolerance*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Dataset is a fully read dataset file.
+type Dataset struct {
+	Transactions []*models.Transaction
+	ItemNames    map[int]string
+	Report       *Report
+
+	// Exact is set for datasets read in exact mode, whose utilities are
+	// integers scaled by 10^Decimals.
+	Exact    bool
+	Decimals int
+}
+
+// ReadFile reads the dataset at path, which may be compressed (see Open) or
+// a binary cache written by WriteBinary. In lenient mode the returned error
+// is only set for I/O errors; the problems found are in Dataset.Report.
+func ReadFile(path string, mode Mode) (*Dataset, error) {
+	return readFile(path, mode, false, 0)
+}
+
+// ReadFileExact is ReadFile in exact mode, see fixed.go. Text datasets are
+// parsed exactly; the float64 utilities of a binary cache must be integers
+// once scaled.
+func ReadFileExact(path string, mode Mode, decimals int) (*Dataset, error) {
+	return readFile(path, mode, true, decimals)
+}
+
+func readFile(path string, mode Mode, exact bool, decimals int) (*Dataset, error) {
+	if isBinary, err := IsBinaryFile(path); err != nil {
+		return nil, err
+	} else if isBinary {
+		d, err := ReadBinaryFile(path)
+		if err == nil && exact {
+			if err := d.ScaleExact(decimals); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		return d, err
+	}
+
+	file, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := NewReader(file, mode)
+	reader.path = path
+	if exact {
+		reader.SetExact(decimals)
+	}
+	transactions, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return &Dataset{
+		Transactions: transactions,
+		ItemNames:    reader.ItemNames(),
+		Report:       reader.Report(),
+		Exact:        exact,
+		Decimals:     decimals,
+	}, nil
+}
@@ -0,0 +1,307 @@
+package dataset
+
+import (
+	"emhun/models"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestReaderStrict(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+		line  int
+	}{
+		{"format", "1 2:3\n", ErrFormat, 1},
+		{"item", "# header\n1 x:3:1 2\n", ErrItem, 2},
+		{"utility", "1 2:3:1 y\n", ErrUtility, 1},
+		{"transaction utility", "1 2:z:1 2\n", ErrTransactionUtility, 1},
+		{"count mismatch", "1:1:1\n1 2:3:1 2 3\n", ErrCountMismatch, 2},
+		{"empty transaction", "\n :0: \n", ErrEmptyTransaction, 2},
+		{"tu mismatch", "1 2:3:1 2\n1 2:4:1 2\n", ErrTUMismatch, 2},
+		{"duplicate item", "1 1:2:1 1\n", ErrDuplicateItem, 1},
+		{"item metadata", "@ITEM=a=apple\n", ErrItem, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.input), Strict).ReadAll()
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v, want a *ParseError", err)
+			}
+			if !errors.Is(err, tt.err) || parseErr.Line != tt.line {
+				t.Errorf("error %v, want %v on line %d", err, tt.err, tt.line)
+			}
+		})
+	}
+}
+
+func TestReaderLenient(t *testing.T) {
+	input := strings.Join([]string{
+		"# comment",
+		"@ITEM=1=apple",
+		"1 2:3:1 2",
+		"1 2:3:1",
+		"1 2:9:1 2",
+		"1 x:3:1 2",
+		"",
+		"3 3:4:2 2",
+		"2 4:-1:2 -3",
+	}, "\n")
+	reader := NewReader(strings.NewReader(input), Lenient)
+	transactions, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Dòng TU sai và item lặp vẫn được giữ, dòng hỏng bị bỏ qua
+	if len(transactions) != 4 {
+		t.Errorf("read %d transactions, want 4", len(transactions))
+	}
+	if tu := transactions[1].TransactionUtility; tu != 9 {
+		t.Errorf("declared TU of a mismatched line read as %g, want 9", tu)
+	}
+	report := reader.Report()
+	if report.Lines != 9 || report.Transactions != 4 || report.Skipped != 2 || report.OK() {
+		t.Errorf("report %+v, want 9 lines, 4 transactions, 2 skipped", report)
+	}
+	want := []struct {
+		line int
+		err  error
+	}{
+		{4, ErrCountMismatch},
+		{5, ErrTUMismatch},
+		{6, ErrItem},
+		{8, ErrDuplicateItem},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("issues %v, want %d", report.Issues, len(want))
+	}
+	for i, w := range want {
+		if issue := report.Issues[i]; issue.Line != w.line || !errors.Is(issue, w.err) {
+			t.Errorf("issue %d is %v, want %v on line %d", i, issue, w.err, w.line)
+		}
+	}
+	if names := reader.ItemNames(); names[1] != "apple" {
+		t.Errorf("item names %v, want 1=apple", names)
+	}
+}
+
+// TestReaderTUMismatch checks which declared TUs are accepted: the sum of
+// the utilities and the sum of the positive ones, up to rounding.
+func TestReaderTUMismatch(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{"1 2:-1:2 -3", true},
+		{"1 2:2:2 -3", true},
+		{"1 2:0.30000000000000004:0.1 0.2", true},
+		{"1 2:5:2 -3", false},
+		{"1 2:-3:2 -3", false},
+	}
+	for _, tt := range tests {
+		_, err := NewReader(strings.NewReader(tt.line), Strict).ReadAll()
+		if ok := err == nil; ok != tt.ok || !ok && !errors.Is(err, ErrTUMismatch) {
+			t.Errorf("%q: error %v, want ok = %t", tt.line, err, tt.ok)
+		}
+	}
+}
+
+func TestReaderLongLine(t *testing.T) {
+	const n = 20000
+	var items, utilities strings.Builder
+	for i := range n {
+		fmt.Fprintf(&items, "%d ", i)
+		utilities.WriteString("1 ")
+	}
+	line := items.String() + ":" + strconv.Itoa(n) + ":" + utilities.String()
+	transactions, err := NewReader(strings.NewReader(line), Strict).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != 1 || len(transactions[0].Items) != n {
+		t.Fatalf("read %d transactions, want one with %d items", len(transactions), n)
+	}
+}
+
+// formatTransaction writes a transaction as an "items:TU:utilities" line.
+func formatTransaction(t *models.Transaction) string {
+	var b strings.Builder
+	for i, item := range t.Items {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.Itoa(item))
+	}
+	b.WriteString(":" + formatFloat(t.TransactionUtility) + ":")
+	for i, utility := range t.Utilities {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(formatFloat(utility))
+	}
+	return b.String()
+}
+
+func randomDataset(r *rand.Rand) *Dataset {
+	d := &Dataset{ItemNames: make(map[int]string)}
+	for range 1 + r.Intn(20) {
+		var items []int
+		var utilities []float64
+		tu := 0.0
+		for _, item := range r.Perm(30)[:1+r.Intn(10)] {
+			u := math.Round(r.NormFloat64()*1e4) / 100
+			if u == 0 {
+				u = 1
+			}
+			items = append(items, item-10)
+			utilities = append(utilities, u)
+			tu += u
+		}
+		d.Transactions = append(d.Transactions, models.NewTransaction(items, utilities, tu))
+	}
+	for item := range 5 {
+		d.ItemNames[item] = "item " + strconv.Itoa(item)
+	}
+	return d
+}
+
+func equalTransactions(a, b []*models.Transaction) bool {
+	return slices.EqualFunc(a, b, func(x, y *models.Transaction) bool {
+		return slices.Equal(x.Items, y.Items) &&
+			slices.EqualFunc(x.Utilities, y.Utilities, func(u, v float64) bool {
+				return math.Float64bits(u) == math.Float64bits(v)
+			}) &&
+			math.Float64bits(x.TransactionUtility) == math.Float64bits(y.TransactionUtility)
+	})
+}
+
+func TestReaderRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 100 {
+		want := randomDataset(r)
+		var text strings.Builder
+		for item, name := range want.ItemNames {
+			text.WriteString("@ITEM=" + strconv.Itoa(item) + "=" + name + "\n")
+		}
+		text.WriteString("# comment\n")
+		for _, transaction := range want.Transactions {
+			text.WriteString(formatTransaction(transaction) + "\n")
+		}
+
+		reader := NewReader(strings.NewReader(text.String()), Strict)
+		got, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalTransactions(got, want.Transactions) {
+			t.Fatalf("read back\n%v\nwant\n%v", got, want.Transactions)
+		}
+		if names := reader.ItemNames(); len(names) != len(want.ItemNames) {
+			t.Fatalf("item names %v, want %v", names, want.ItemNames)
+		}
+	}
+}
+
+func TestReaderExactRoundTrip(t *testing.T) {
+	const decimals = 2
+	r := rand.New(rand.NewSource(2))
+	for range 100 {
+		want := randomDataset(r)
+		var text strings.Builder
+		for _, transaction := range want.Transactions {
+			text.WriteString(formatTransaction(transaction) + "\n")
+		}
+		reader := NewReader(strings.NewReader(text.String()), Strict)
+		reader.SetExact(decimals)
+		got, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Giá trị đọc ở chế độ exact phải đúng bằng giá trị float nhân 100
+		for i, transaction := range want.Transactions {
+			for j, u := range transaction.Utilities {
+				if scaled := got[i].Utilities[j]; scaled != math.Round(u*100) {
+					t.Fatalf("utility %s read as %g units", formatFloat(u), scaled)
+				}
+			}
+		}
+	}
+}
+
+func FuzzReader(f *testing.F) {
+	f.Add("1 2 3:6:1 2 3\n")
+	f.Add("@ITEM=1=apple\n1 2:-1:3 -4\n# comment\n\n3:5.5:5.5")
+	f.Add("1 1:2:1 1\n1 2:9:1 2\n1 2 3:4:1 2\n::\n")
+	f.Fuzz(func(t *testing.T, text string) {
+		reader := NewReader(strings.NewReader(text), Lenient)
+		transactions, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := reader.Report()
+		if report.Transactions != len(transactions) || report.Transactions+report.Skipped > report.Lines {
+			t.Fatalf("report %+v for %d transactions", report, len(transactions))
+		}
+
+		// Các giao dịch đọc được phải đọc lại y hệt sau khi ghi ra
+		var text2 strings.Builder
+		for _, transaction := range transactions {
+			text2.WriteString(formatTransaction(transaction) + "\n")
+		}
+		again, err := NewReader(strings.NewReader(text2.String()), Lenient).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalTransactions(again, transactions) {
+			t.Fatalf("read back\n%v\nwant\n%v", again, transactions)
+		}
+	})
+}
+
+func FuzzParseFixed(f *testing.F) {
+	for _, s := range []string{"0", "-152.46", "+3.", ".5", "12.3450", "9223372036854775807", "1e3", ""} {
+		f.Add(s, 2)
+	}
+	f.Fuzz(func(t *testing.T, s string, decimals int) {
+		decimals = int(uint(decimals) % 19)
+		v, err := ParseFixed(s, decimals)
+		c, cerr := ParseFixedCeil(s, decimals)
+		if cerr != nil {
+			if err == nil {
+				t.Fatalf("ParseFixed(%q) = %d but ParseFixedCeil failed: %v", s, v, cerr)
+			}
+			return
+		}
+
+		// So với phép tính chính xác bằng big.Rat
+		exact, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+		if !ok {
+			t.Fatalf("ParseFixedCeil accepted %q", s)
+		}
+		exact.Mul(exact, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)))
+		ceil, m := new(big.Int).DivMod(exact.Num(), exact.Denom(), new(big.Int))
+		if m.Sign() != 0 {
+			ceil.Add(ceil, big.NewInt(1))
+		}
+		if ceil.Cmp(big.NewInt(c)) != 0 {
+			t.Fatalf("ParseFixedCeil(%q, %d) = %d, want %s", s, decimals, c, ceil)
+		}
+		switch {
+		case exact.IsInt() && (err != nil || v != c):
+			t.Fatalf("ParseFixed(%q, %d) = %d, %v, want %d", s, decimals, v, err, c)
+		case !exact.IsInt() && !errors.Is(err, ErrPrecision):
+			t.Fatalf("ParseFixed(%q, %d) = %d, %v, want ErrPrecision", s, decimals, v, err)
+		}
+	})
+}
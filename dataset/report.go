@@ -0,0 +1,23 @@
+package dataset
+
+// Report lists every inconsistency found while reading a dataset.
+type Report struct {
+	// Lines counts every line read, including comments and metadata.
+	Lines        int
+	Transactions int
+	// Skipped counts the lines dropped in lenient mode.
+	Skipped int
+	Issues  []*ParseError
+}
+
+// OK reports whether the dataset was read without any issue.
+func (r *Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+func (r *Report) add(err *ParseError) {
+	r.Issues = append(r.Issues, err)
+	if skipsLine(err.Err) {
+		r.Skipped++
+	}
+}
@@ -0,0 +1,13 @@
+package dataset
+
+import "errors"
+
+// ErrThreshold is returned for a minimum utility that is not a number.
+var ErrThreshold = errors.New("invalid minimum utility")
+
+// ReadThresholdTable reads per-item minimum utilities, with one
+// "item threshold" pair per line, in the format of ReadProfitTable. Items
+// not in the table keep the global minimum utility.
+func ReadThresholdTable(path string) (map[int]float64, error) {
+	return readItemTable(path, ErrThreshold)
+}
@@ -0,0 +1,43 @@
+package dataset
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+func TestReadThresholdTable(t *testing.T) {
+	thresholds, err := ReadThresholdTable(writeTemp(t, "thresholds.txt", "% item minutil\n1 40\n\n2 2.5\n# rượu\n3 1e3\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[int]float64{1: 40, 2: 2.5, 3: 1000}; !maps.Equal(thresholds, want) {
+		t.Errorf("thresholds %v, want %v", thresholds, want)
+	}
+}
+
+func TestReadThresholdTableErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		err   error
+		line  int
+	}{
+		{"missing threshold", "1 40\n2\n", ErrFormat, 2},
+		{"extra field", "1 40 50\n", ErrFormat, 1},
+		{"item:threshold", "1:40\n", ErrFormat, 1},
+		{"bad item", "1 40\nx 5\n", ErrItem, 2},
+		{"bad threshold", "1 forty\n", ErrThreshold, 1},
+		// Ngưỡng thứ hai của cùng item không được âm thầm ghi đè ngưỡng đầu
+		{"duplicate item", "1 40\n2 5\n1 60\n", ErrDuplicateItem, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadThresholdTable(writeTemp(t, "thresholds.txt", tt.input))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || !errors.Is(err, tt.err) || parseErr.Line != tt.line {
+				t.Errorf("%q: error %v, want %v on line %d", tt.input, err, tt.err, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package dataset
+
+import (
+	"bufio"
+	"emhun/models"
+	"io"
+	"strconv"
+)
+
+// Writer writes transactions in the format read by Reader.
+type Writer struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// WriteItemName writes an "@ITEM=id=name" line.
+func (w *Writer) WriteItemName(item int, name string) error {
+	_, err := w.w.WriteString("@ITEM=" + strconv.Itoa(item) + "=" + name + "\n")
+	return err
+}
+
+// Write writes t as an "items:TU:utilities" line. Numbers are written in
+// plain decimal notation, which exact mode can read as well.
+func (w *Writer) Write(t *models.Transaction) error {
+	b := w.buf[:0]
+	for i, item := range t.Items {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendInt(b, int64(item), 10)
+	}
+	b = append(b, ':')
+	b = strconv.AppendFloat(b, t.TransactionUtility, 'f', -1, 64)
+	b = append(b, ':')
+	for i, utility := range t.Utilities {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendFloat(b, utility, 'f', -1, 64)
+	}
+	b = append(b, '\n')
+	w.buf = b
+	_, err := w.w.Write(b)
+	return err
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,68 @@
+package dataset
+
+import (
+	"emhun/models"
+	"maps"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestWriterRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for range 100 {
+		want := randomDataset(r)
+		var text strings.Builder
+		w := NewWriter(&text)
+		for item, name := range want.ItemNames {
+			w.WriteItemName(item, name)
+		}
+		for _, transaction := range want.Transactions {
+			w.Write(transaction)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		reader := NewReader(strings.NewReader(text.String()), Strict)
+		got, err := reader.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalTransactions(got, want.Transactions) {
+			t.Fatalf("read back\n%v\nwant\n%v", got, want.Transactions)
+		}
+		if names := reader.ItemNames(); !maps.Equal(names, want.ItemNames) {
+			t.Fatalf("item names %v, want %v", names, want.ItemNames)
+		}
+	}
+}
+
+// TestWriterFormat checks that numbers are written in plain decimal
+// notation, which exact mode reads too.
+func TestWriterFormat(t *testing.T) {
+	tests := []struct {
+		transaction *models.Transaction
+		line        string
+	}{
+		{models.NewTransaction([]int{1, 2}, []float64{1, 2.5}, 3.5), "1 2:3.5:1 2.5\n"},
+		{models.NewTransaction([]int{-3, 7}, []float64{-4, 2}, -2), "-3 7:-2:-4 2\n"},
+		{models.NewTransaction([]int{5}, []float64{1e21}, 1e21), "5:1000000000000000000000:1000000000000000000000\n"},
+		{models.NewTransaction([]int{5}, []float64{1e-6}, 1e-6), "5:0.000001:0.000001\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		w := NewWriter(&b)
+		w.Write(tt.transaction)
+		w.Flush()
+		if b.String() != tt.line {
+			t.Errorf("%v written as %q, want %q", tt.transaction, b.String(), tt.line)
+		}
+	}
+
+	reader := NewReader(strings.NewReader(tests[3].line), Strict)
+	reader.SetExact(6)
+	if got, err := reader.ReadAll(); err != nil || got[0].Utilities[0] != 1 {
+		t.Errorf("exact mode read %v, %v, want 1 unit", got, err)
+	}
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CSV writes an "items,utility" header and one row per itemset in canonical
+// order. The items of a row are separated by spaces. In closed mode a
+// support column is added.
+type CSV struct{}
+
+func (CSV) Write(w io.Writer, r *Result) error {
+	writer := csv.NewWriter(w)
+	if r.Closed != nil {
+		if err := writer.Write([]string{"items", "utility", "support"}); err != nil {
+			return err
+		}
+		for _, c := range r.Closed {
+			record := []string{joinItems(c.Itemset), formatUtility(c.Utility), strconv.Itoa(c.Support)}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	if err := writer.Write([]string{"items", "utility"}); err != nil {
+		return err
+	}
+	for _, hui := range Canonical(r.Itemsets) {
+		record := []string{joinItems(hui.Itemset), formatUtility(hui.Utility)}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func joinItems(itemset []int) string {
+	items := make([]string, len(itemset))
+	for i, item := range itemset {
+		items[i] = strconv.Itoa(item)
+	}
+	return strings.Join(items, " ")
+}
+
+func formatUtility(u float64) string {
+	return strconv.FormatFloat(u, 'f', -1, 64)
+}
@@ -0,0 +1,133 @@
+// Package export writes mining results in the formats supported by the
+// command line: the original text report, JSON, JSON Lines, CSV and SPMF.
+package export
+
+import (
+	"emhun/algorithms"
+	"emhun/models"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Result is a mining run as seen by the writers: the itemsets and the
+// metadata of the run that produced them.
+type Result struct {
+	Dataset         string
+	Engine          string
+	MinUtility      float64
+	MinUtilityRatio float64
+	TopK            int
+	ElapsedSeconds  float64
+	MemoryKB        uint64
+	Status          algorithms.RunStatus
+	Stats           algorithms.Stats
+	Itemsets        []*models.HighUtilityItemset
+	// Closed is non-nil for runs in closed mode; the writers then write
+	// the closed itemsets instead of Itemsets.
+	Closed []*models.ClosedItemset
+	// ItemNames holds the names declared by the dataset, if any.
+	ItemNames map[int]string
+	// Decimals is set by Unscale for runs in exact mode.
+	Decimals *int
+}
+
+// NewResult collects the result of a finished run of e on dataset.
+func NewResult(e *algorithms.EMHUN, dataset string, elapsedSeconds float64, memoryKB uint64) *Result {
+	r := &Result{
+		Dataset:         dataset,
+		Engine:          string(e.Engine),
+		MinUtility:      e.MinUtility,
+		MinUtilityRatio: e.MinUtilityRatio,
+		TopK:            e.SearchAlgorithms.K,
+		ElapsedSeconds:  elapsedSeconds,
+		MemoryKB:        memoryKB,
+		Status:          e.Status,
+		Stats:           e.Stats,
+		Itemsets:        e.SearchAlgorithms.HighUtilityItemsets,
+	}
+	if e.Closed {
+		r.Closed = append(make([]*models.ClosedItemset, 0, len(e.ClosedItemsets)), e.ClosedItemsets...)
+	}
+	return r
+}
+
+// Unscale converts the utilities and the threshold of a run in exact mode,
+// which are integers scaled by 10^decimals, back to the units of the dataset.
+func (r *Result) Unscale(decimals int) {
+	scale := math.Pow10(decimals)
+	itemsets := make([]*models.HighUtilityItemset, len(r.Itemsets))
+	for i, hui := range r.Itemsets {
+		itemsets[i] = models.NewHighUtilityItemset(hui.Itemset, hui.Utility/scale)
+	}
+	r.Itemsets = itemsets
+	if r.Closed != nil {
+		closed := make([]*models.ClosedItemset, len(r.Closed))
+		for i, c := range r.Closed {
+			unscaled := *c
+			unscaled.Utility /= scale
+			unscaled.ItemUtilities = make([]float64, len(c.ItemUtilities))
+			for j, u := range c.ItemUtilities {
+				unscaled.ItemUtilities[j] = u / scale
+			}
+			closed[i] = &unscaled
+		}
+		r.Closed = closed
+	}
+	r.MinUtility /= scale
+	r.Decimals = &decimals
+}
+
+// Writer writes a Result in one format.
+type Writer interface {
+	Write(w io.Writer, r *Result) error
+}
+
+var writers = map[string]Writer{
+	"text":  Text{},
+	"json":  JSON{},
+	"jsonl": JSONLines{},
+	"csv":   CSV{},
+	"spmf":  SPMF{},
+}
+
+// Register makes a writer available under name, replacing any writer
+// registered before with the same name.
+func Register(name string, w Writer) {
+	writers[name] = w
+}
+
+// Lookup returns the writer registered under name.
+func Lookup(name string) (Writer, error) {
+	w, ok := writers[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q", name)
+	}
+	return w, nil
+}
+
+// Formats returns the registered format names in sorted order.
+func Formats() []string {
+	names := make([]string, 0, len(writers))
+	for name := range writers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Canonical returns copies of itemsets with the items of each itemset in
+// ascending order, sorted by their items. Two runs finding the same itemsets
+// give the same canonical list regardless of engine, workers or top-k order.
+func Canonical(itemsets []*models.HighUtilityItemset) []*models.HighUtilityItemset {
+	sorted := make([]*models.HighUtilityItemset, len(itemsets))
+	for i, hui := range itemsets {
+		sorted[i] = models.NewHighUtilityItemset(slices.Sorted(slices.Values(hui.Itemset)), hui.Utility)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return slices.Compare(sorted[i].Itemset, sorted[j].Itemset) < 0
+	})
+	return sorted
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"bytes"
+	"emhun/algorithms"
+	"emhun/models"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenResult has itemsets out of canonical order and with unsorted items,
+// and a negative utility.
+func goldenResult() *Result {
+	return &Result{
+		Dataset:        "table3.txt",
+		Engine:         "projection",
+		MinUtility:     30,
+		ElapsedSeconds: 0.5,
+		MemoryKB:       12,
+		Status:         algorithms.RunStatus{Complete: true},
+		Itemsets: []*models.HighUtilityItemset{
+			models.NewHighUtilityItemset([]int{5, 4}, 37),
+			models.NewHighUtilityItemset([]int{4, 2, 5}, 31),
+			models.NewHighUtilityItemset([]int{3}, -2.5),
+		},
+	}
+}
+
+// checkGolden compares got with testdata/name, after rewriting the file
+// when the test runs with -update.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+func TestWritersGolden(t *testing.T) {
+	for _, format := range []string{"json", "jsonl", "csv"} {
+		t.Run(format, func(t *testing.T) {
+			w, err := Lookup(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := w.Write(&buf, goldenResult()); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, "result."+format, buf.Bytes())
+		})
+	}
+}
+
+// TestSPMFGolden checks that the spmf writer uses the declared names and
+// falls back to the id for the other items.
+func TestSPMFGolden(t *testing.T) {
+	r := goldenResult()
+	r.ItemNames = map[int]string{4: "milk", 5: "bread"}
+	var buf bytes.Buffer
+	if err := (SPMF{}).Write(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "result.spmf", buf.Bytes())
+}
+
+// TestClosedJSONRoundTrip checks that ReadJSON gives back the closed
+// itemsets written by the json writer, which recover relies on.
+func TestClosedJSONRoundTrip(t *testing.T) {
+	want := goldenResult()
+	want.Itemsets = nil
+	want.Closed = []*models.ClosedItemset{{
+		Itemset:       []int{3, 4, 5},
+		Utility:       37,
+		Support:       3,
+		ItemUtilities: []float64{11, 22, 4},
+		Generators:    [][]int{{3, 4}},
+	}}
+	var buf bytes.Buffer
+	if err := (JSON{}).Write(&buf, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadJSON(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.MinUtility != want.MinUtility || !reflect.DeepEqual(got.Closed, want.Closed) {
+		t.Errorf("read back %+v, want %+v", got.Closed, want.Closed)
+	}
+}
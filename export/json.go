@@ -0,0 +1,132 @@
+package export
+
+import (
+	"bufio"
+	"emhun/algorithms"
+	"emhun/models"
+	"encoding/json"
+	"io"
+)
+
+type jsonItemset struct {
+	Items   []int   `json:"items"`
+	Utility float64 `json:"utility"`
+	// Set in closed mode only.
+	Support       int       `json:"support,omitempty"`
+	ItemUtilities []float64 `json:"item_utilities,omitempty"`
+	Generators    [][]int   `json:"generators,omitempty"`
+}
+
+type jsonDocument struct {
+	Dataset         string           `json:"dataset"`
+	Engine          string           `json:"engine"`
+	MinUtility      float64          `json:"min_utility"`
+	MinUtilityRatio float64          `json:"min_utility_ratio,omitempty"`
+	TopK            int              `json:"top_k,omitempty"`
+	Closed          bool             `json:"closed,omitempty"`
+	ExactDecimals   *int             `json:"exact_decimals,omitempty"`
+	RuntimeSeconds  float64          `json:"runtime_seconds"`
+	MemoryKB        uint64           `json:"memory_kb"`
+	Complete        bool             `json:"complete"`
+	StopReason      string           `json:"stop_reason,omitempty"`
+	Stats           algorithms.Stats `json:"stats"`
+	Itemsets        []jsonItemset    `json:"itemsets"`
+}
+
+func jsonItemsets(r *Result) []jsonItemset {
+	if r.Closed != nil {
+		itemsets := make([]jsonItemset, 0, len(r.Closed))
+		for _, c := range r.Closed {
+			itemsets = append(itemsets, jsonItemset{
+				Items:         c.Itemset,
+				Utility:       c.Utility,
+				Support:       c.Support,
+				ItemUtilities: c.ItemUtilities,
+				Generators:    c.Generators,
+			})
+		}
+		return itemsets
+	}
+	itemsets := make([]jsonItemset, 0, len(r.Itemsets))
+	for _, hui := range Canonical(r.Itemsets) {
+		itemsets = append(itemsets, jsonItemset{Items: hui.Itemset, Utility: hui.Utility})
+	}
+	return itemsets
+}
+
+// JSON writes one document holding the run metadata, the statistics and the
+// itemsets in canonical order. In closed mode each itemset also carries its
+// support, item utilities and generators, which is what RecoverHUIs needs.
+type JSON struct{}
+
+func (JSON) Write(w io.Writer, r *Result) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(jsonDocument{
+		Dataset:         r.Dataset,
+		Engine:          r.Engine,
+		MinUtility:      r.MinUtility,
+		MinUtilityRatio: r.MinUtilityRatio,
+		TopK:            r.TopK,
+		Closed:          r.Closed != nil,
+		ExactDecimals:   r.Decimals,
+		RuntimeSeconds:  r.ElapsedSeconds,
+		MemoryKB:        r.MemoryKB,
+		Complete:        r.Status.Complete,
+		StopReason:      string(r.Status.Reason),
+		Stats:           r.Stats,
+		Itemsets:        jsonItemsets(r),
+	})
+}
+
+// JSONLines writes one {"items": [...], "utility": u} object per line, in
+// canonical order, so that large results can be processed as a stream.
+type JSONLines struct{}
+
+func (JSONLines) Write(w io.Writer, r *Result) error {
+	writer := bufio.NewWriter(w)
+	encoder := json.NewEncoder(writer)
+	for _, itemset := range jsonItemsets(r) {
+		if err := encoder.Encode(itemset); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadJSON reads a document written by JSON back into a Result. The run
+// statistics are not restored.
+func ReadJSON(r io.Reader) (*Result, error) {
+	var doc jsonDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Dataset:         doc.Dataset,
+		Engine:          doc.Engine,
+		MinUtility:      doc.MinUtility,
+		MinUtilityRatio: doc.MinUtilityRatio,
+		TopK:            doc.TopK,
+		ElapsedSeconds:  doc.RuntimeSeconds,
+		MemoryKB:        doc.MemoryKB,
+		Status:          algorithms.RunStatus{Complete: doc.Complete, Reason: algorithms.StopReason(doc.StopReason)},
+		Decimals:        doc.ExactDecimals,
+	}
+	if doc.Closed {
+		result.Closed = make([]*models.ClosedItemset, 0, len(doc.Itemsets))
+		for _, itemset := range doc.Itemsets {
+			result.Closed = append(result.Closed, &models.ClosedItemset{
+				Itemset:       itemset.Items,
+				Utility:       itemset.Utility,
+				Support:       itemset.Support,
+				ItemUtilities: itemset.ItemUtilities,
+				Generators:    itemset.Generators,
+			})
+		}
+		return result, nil
+	}
+	for _, itemset := range doc.Itemsets {
+		result.Itemsets = append(result.Itemsets, models.NewHighUtilityItemset(itemset.Items, itemset.Utility))
+	}
+	return result, nil
+}
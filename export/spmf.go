@@ -0,0 +1,49 @@
+package export
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+)
+
+// SPMF writes one "items #UTIL: u" line per itemset in canonical order, the
+// output format of the SPMF library. Items declared by @ITEM lines in the
+// input are written by name. In closed mode lines end with " #SUP: s", as
+// in the output of the SPMF closed HUI miners.
+type SPMF struct{}
+
+func (SPMF) Write(w io.Writer, r *Result) error {
+	writer := bufio.NewWriter(w)
+	writeLine := func(itemset []int, utility float64, support int) error {
+		for _, item := range itemset {
+			if name, ok := r.ItemNames[item]; ok {
+				writer.WriteString(name)
+			} else {
+				writer.WriteString(strconv.Itoa(item))
+			}
+			writer.WriteByte(' ')
+		}
+		writer.WriteString("#UTIL: ")
+		writer.WriteString(formatUtility(utility))
+		if r.Closed != nil {
+			writer.WriteString(" #SUP: ")
+			writer.WriteString(strconv.Itoa(support))
+		}
+		return writer.WriteByte('\n')
+	}
+
+	if r.Closed != nil {
+		for _, c := range r.Closed {
+			if err := writeLine(c.Itemset, c.Utility, c.Support); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	}
+	for _, hui := range Canonical(r.Itemsets) {
+		if err := writeLine(hui.Itemset, hui.Utility, 0); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
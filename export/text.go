@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Text writes the original report: one "Itemset: [...], Utility: ..." line
+// per itemset in the order they were found, followed by the threshold, the
+// running time and the memory used. In closed mode the closed itemsets are
+// written with their support.
+type Text struct{}
+
+func (Text) Write(w io.Writer, r *Result) error {
+	writer := bufio.NewWriter(w)
+
+	// Ghi kết quả thuật toán
+	if r.Closed != nil {
+		for _, c := range r.Closed {
+			line := fmt.Sprintf("Itemset: %v, Utility: %.2f, Support: %d\n", c.Itemset, c.Utility, c.Support)
+			if _, err := writer.WriteString(line); err != nil {
+				return err
+			}
+		}
+	} else {
+		for _, hui := range r.Itemsets {
+			line := fmt.Sprintf("Itemset: %v, Utility: %.2f\n", hui.Itemset, hui.Utility)
+			_, err := writer.WriteString(line)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// Ghi ngưỡng minUtility đã dùng, kèm tỉ lệ nếu ngưỡng được cho dưới dạng tương đối
+	threshold := fmt.Sprintf("\nNgưỡng minUtility: %.2f\n", r.MinUtility)
+	if r.MinUtilityRatio != 0 {
+		threshold = fmt.Sprintf("\nNgưỡng minUtility: %.2f (%g%% tổng tiện ích dương)\n", r.MinUtility, r.MinUtilityRatio*100)
+	} else if r.TopK > 0 {
+		threshold = fmt.Sprintf("\nNgưỡng minUtility: %.2f (top-%d)\n", r.MinUtility, r.TopK)
+	}
+	_, err := writer.WriteString(threshold)
+	if err != nil {
+		return err
+	}
+
+	// Ghi thông tin về thời gian (theo giây) và bộ nhớ
+	_, err = writer.WriteString(fmt.Sprintf("Thời gian chạy thuật toán: %.6f giây\n", r.ElapsedSeconds))
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.WriteString(fmt.Sprintf("Bộ nhớ sử dụng: %d KB\n", r.MemoryKB))
+	if err != nil {
+		return err
+	}
+
+	if !r.Status.Complete {
+		_, err = writer.WriteString(fmt.Sprintf("Kết quả chưa đầy đủ: %s\n", r.Status.Reason))
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,282 @@
+// Package generator builds synthetic transaction databases with positive,
+// hybrid and negative items, for scale tests beyond the files in data/.
+package generator
+
+import (
+	"emhun/dataset"
+	"emhun/models"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Class is the class an item is generated for, as EMHUN.ClassifyItems
+// defines them.
+type Class int
+
+const (
+	Positive Class = iota // ρ: only positive utilities
+	Hybrid                // δ: both positive and negative utilities
+	Negative              // η: only negative utilities
+)
+
+func (c Class) String() string {
+	switch c {
+	case Positive:
+		return "positive"
+	case Hybrid:
+		return "hybrid"
+	case Negative:
+		return "negative"
+	}
+	return fmt.Sprintf("Class(%d)", int(c))
+}
+
+// Config describes a database to generate.
+type Config struct {
+	Transactions int
+	// Items is the size of the item universe; items are numbered 1..Items,
+	// item 1 being the most popular.
+	Items int
+	// AvgLength is the mean number of items per transaction. Lengths follow
+	// a Poisson distribution, clamped to [1, Items].
+	AvgLength float64
+	// Zipf is the exponent s of the item popularity: the item of rank r is
+	// drawn with probability proportional to 1/r^s. Zero is uniform.
+	Zipf float64
+
+	// Positive, Hybrid and Negative are the proportions of items in each
+	// class. They must add up to 1.
+	Positive, Hybrid, Negative float64
+	// HybridNegative is the probability that an occurrence of a hybrid item
+	// has a negative utility.
+	HybridNegative float64
+
+	// MaxUtility bounds the absolute utility of an occurrence; utilities are
+	// drawn uniformly from (0, MaxUtility] in steps of 10^-Decimals.
+	MaxUtility float64
+	Decimals   int
+
+	Seed int64
+}
+
+// DefaultConfig returns a small configuration with most items positive.
+func DefaultConfig() Config {
+	return Config{
+		Transactions:   1000,
+		Items:          100,
+		AvgLength:      10,
+		Zipf:           1,
+		Positive:       0.6,
+		Hybrid:         0.3,
+		Negative:       0.1,
+		HybridNegative: 0.5,
+		MaxUtility:     10,
+		Seed:           1,
+	}
+}
+
+// ErrConfig is returned for configurations that cannot be generated.
+var ErrConfig = errors.New("invalid generator config")
+
+func (c Config) validate() error {
+	switch {
+	case c.Transactions < 0:
+		return fmt.Errorf("%w: negative number of transactions", ErrConfig)
+	case c.Items < 1:
+		return fmt.Errorf("%w: at least one item is required", ErrConfig)
+	case c.AvgLength < 1:
+		return fmt.Errorf("%w: average length must be at least 1", ErrConfig)
+	case c.Zipf < 0:
+		return fmt.Errorf("%w: negative Zipf exponent", ErrConfig)
+	case c.Positive < 0 || c.Hybrid < 0 || c.Negative < 0:
+		return fmt.Errorf("%w: negative class proportion", ErrConfig)
+	case math.Abs(c.Positive+c.Hybrid+c.Negative-1) > 1e-9:
+		return fmt.Errorf("%w: class proportions add up to %g, not 1", ErrConfig, c.Positive+c.Hybrid+c.Negative)
+	case c.HybridNegative < 0 || c.HybridNegative > 1:
+		return fmt.Errorf("%w: hybrid negative probability must be in [0, 1]", ErrConfig)
+	case c.Decimals < 0 || c.Decimals > 6:
+		return fmt.Errorf("%w: decimals must be between 0 and 6", ErrConfig)
+	case c.MaxUtility*math.Pow10(c.Decimals) < 1:
+		return fmt.Errorf("%w: max utility is below one unit", ErrConfig)
+	}
+	return nil
+}
+
+// Generator produces the transactions of a Config one at a time, so that
+// large databases can be written without holding them in memory. The same
+// Config always produces the same transactions.
+type Generator struct {
+	config  Config
+	rand    *rand.Rand
+	classes []Class   // indexed by item
+	cdf     []float64 // cumulative popularity, indexed by item-1
+	units   int64     // MaxUtility in steps of 10^-Decimals
+	scale   float64
+	done    int
+
+	// Dấu của lần xuất hiện đầu tiên của mỗi item δ, để lần thứ hai mang
+	// dấu ngược lại và item thực sự là δ
+	firstSign []int8
+	seen      []int
+}
+
+func New(config Config) (*Generator, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	g := &Generator{
+		config:    config,
+		rand:      rand.New(rand.NewSource(config.Seed)),
+		classes:   make([]Class, config.Items+1),
+		cdf:       make([]float64, config.Items),
+		scale:     math.Pow10(config.Decimals),
+		firstSign: make([]int8, config.Items+1),
+		seen:      make([]int, config.Items+1),
+	}
+	g.units = int64(math.Round(config.MaxUtility * g.scale))
+
+	total := 0.0
+	for i := range g.cdf {
+		total += math.Pow(float64(i+1), -config.Zipf)
+		g.cdf[i] = total
+	}
+	for i := range g.cdf {
+		g.cdf[i] /= total
+	}
+
+	// Gán lớp cho item một cách ngẫu nhiên, độc lập với độ phổ biến
+	hybrid := int(math.Round(config.Hybrid * float64(config.Items)))
+	negative := min(int(math.Round(config.Negative*float64(config.Items))), config.Items-hybrid)
+	for i, item := range g.rand.Perm(config.Items) {
+		switch {
+		case i < hybrid:
+			g.classes[item+1] = Hybrid
+		case i < hybrid+negative:
+			g.classes[item+1] = Negative
+		default:
+			g.classes[item+1] = Positive
+		}
+	}
+	return g, nil
+}
+
+// Class returns the class item was generated for. An item only ends up in
+// that class once it occurs in the database, and a hybrid item needs at
+// least two occurrences to get both signs.
+func (g *Generator) Class(item int) Class {
+	return g.classes[item]
+}
+
+// Next returns the next transaction, or nil once Config.Transactions have
+// been generated. Items are in ascending order and the TU is the sum of the
+// positive utilities.
+func (g *Generator) Next() *models.Transaction {
+	if g.done == g.config.Transactions {
+		return nil
+	}
+	g.done++
+
+	length := min(max(g.poisson(g.config.AvgLength), 1), g.config.Items)
+	items := g.sample(length)
+	sort.Ints(items)
+
+	// Cộng theo đơn vị nguyên để TU không mang sai số float
+	utilities := make([]float64, len(items))
+	var tu int64
+	for i, item := range items {
+		u := 1 + g.rand.Int63n(g.units)
+		if g.negative(item) {
+			u = -u
+		} else {
+			tu += u
+		}
+		utilities[i] = float64(u) / g.scale
+	}
+	return models.NewTransaction(items, utilities, float64(tu)/g.scale)
+}
+
+// negative decides the sign of an occurrence of item.
+func (g *Generator) negative(item int) bool {
+	switch g.classes[item] {
+	case Positive:
+		return false
+	case Negative:
+		return true
+	}
+	g.seen[item]++
+	var negative bool
+	switch g.seen[item] {
+	case 1:
+		negative = g.rand.Float64() < g.config.HybridNegative
+		g.firstSign[item] = 1
+		if negative {
+			g.firstSign[item] = -1
+		}
+	case 2:
+		negative = g.firstSign[item] > 0
+	default:
+		negative = g.rand.Float64() < g.config.HybridNegative
+	}
+	return negative
+}
+
+// sample draws length distinct items by popularity.
+func (g *Generator) sample(length int) []int {
+	chosen := make(map[int]bool, length)
+	items := make([]int, 0, length)
+	for attempts := 0; len(items) < length && attempts < 4*length+100; attempts++ {
+		item := sort.SearchFloat64s(g.cdf, g.rand.Float64()) + 1
+		if item <= g.config.Items && !chosen[item] {
+			chosen[item] = true
+			items = append(items, item)
+		}
+	}
+	// Với phân phối rất lệch, phần còn lại được chọn đều trong các item chưa có
+	if len(items) < length {
+		for _, i := range g.rand.Perm(g.config.Items) {
+			if len(items) == length {
+				break
+			}
+			if !chosen[i+1] {
+				chosen[i+1] = true
+				items = append(items, i+1)
+			}
+		}
+	}
+	return items
+}
+
+func (g *Generator) poisson(mean float64) int {
+	if mean > 30 {
+		return int(math.Round(mean + math.Sqrt(mean)*g.rand.NormFloat64()))
+	}
+	// Thuật toán của Knuth
+	limit, k, p := math.Exp(-mean), 0, 1.0
+	for {
+		p *= g.rand.Float64()
+		if p <= limit {
+			return k
+		}
+		k++
+	}
+}
+
+// Write generates the database described by config and writes it to w in
+// the items:TU:utilities format.
+func Write(w io.Writer, config Config) error {
+	g, err := New(config)
+	if err != nil {
+		return err
+	}
+	writer := dataset.NewWriter(w)
+	for t := g.Next(); t != nil; t = g.Next() {
+		if err := writer.Write(t); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"bytes"
+	"emhun/algorithms"
+	"emhun/dataset"
+	"emhun/models"
+	"math"
+	"testing"
+)
+
+func generate(t *testing.T, config Config) (*Generator, []*models.Transaction) {
+	t.Helper()
+	g, err := New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var transactions []*models.Transaction
+	for transaction := g.Next(); transaction != nil; transaction = g.Next() {
+		transactions = append(transactions, transaction)
+	}
+	return g, transactions
+}
+
+func TestClassesMatchClassifyItems(t *testing.T) {
+	config := DefaultConfig()
+	config.Transactions = 2000
+	g, transactions := generate(t, config)
+
+	occurrences := make(map[int]int)
+	length := 0
+	for _, transaction := range transactions {
+		length += len(transaction.Items)
+		for _, item := range transaction.Items {
+			occurrences[item]++
+		}
+	}
+	if avg := float64(length) / float64(len(transactions)); math.Abs(avg-config.AvgLength) > 0.5 {
+		t.Errorf("average length %g, want about %g", avg, config.AvgLength)
+	}
+	if occurrences[1] <= occurrences[config.Items] {
+		t.Errorf("item 1 occurs %d times, item %d %d times", occurrences[1], config.Items, occurrences[config.Items])
+	}
+
+	e := algorithms.NewEMHUN(transactions, 1)
+	e.ClassifyItems()
+	counts := make(map[Class]int)
+	for item := 1; item <= config.Items; item++ {
+		class := g.Class(item)
+		counts[class]++
+		if occurrences[item] < 2 {
+			continue
+		}
+		var ok bool
+		switch class {
+		case Positive:
+			ok = e.Rho[item]
+		case Hybrid:
+			ok = e.Delta[item]
+		case Negative:
+			ok = e.Eta[item]
+		}
+		if !ok {
+			t.Errorf("item %d generated as %s, classified otherwise", item, class)
+		}
+	}
+	if counts[Positive] != 60 || counts[Hybrid] != 30 || counts[Negative] != 10 {
+		t.Errorf("class counts %v", counts)
+	}
+}
+
+func TestWriteIsReproducible(t *testing.T) {
+	config := DefaultConfig()
+	config.Decimals = 2
+	var a, b bytes.Buffer
+	if err := Write(&a, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(&b, config); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Fatal("same config wrote different datasets")
+	}
+
+	// File sinh ra phải đọc được ở chế độ strict và exact
+	reader := dataset.NewReader(&a, dataset.Strict)
+	reader.SetExact(config.Decimals)
+	transactions, err := reader.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transactions) != config.Transactions {
+		t.Fatalf("read %d transactions, want %d", len(transactions), config.Transactions)
+	}
+}
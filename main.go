@@ -1,142 +1,75 @@
 package main
 
 import (
-	"bufio"
-	"emhun/algorithms"
-	"emhun/models"
+	"emhun/export"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
-	"strconv"
-	"strings"
-	"time"
 )
 
-func main() {
-	fileName := "data/BMS.txt"
-	minUtility := 2000000.0
-
-	// Đo thời gian bắt đầu
-	startTime := time.Now()
-
-	// Đo bộ nhớ trước khi chạy thuật toán
-	var memStatsBefore, memStatsAfter runtime.MemStats
-	runtime.ReadMemStats(&memStatsBefore)
-
-	transactions, err := readTransactionsFromFile(fileName)
-	if err != nil {
-		fmt.Println("Error reading transactions:", err)
-		return
-	}
-	fmt.Println("Transactions vừa đọc được:")
-	for i, transaction := range transactions {
-		fmt.Printf("Transaction %d: %s\n", i+1, transaction)
-	}
-	emhun := algorithms.NewEMHUN(transactions, minUtility)
-
-	emhun.Run()
-
-	elapsedTime := time.Since(startTime).Seconds()
-	fmt.Printf("\nThời gian chạy thuật toán: %.6f s\n", elapsedTime)
-
-	// Đo bộ nhớ sau khi chạy thuật toán
-	runtime.ReadMemStats(&memStatsAfter)
-	allocatedMemory := (memStatsAfter.Alloc - memStatsBefore.Alloc) / 1024
-	fmt.Printf("Bộ nhớ sử dụng: %d KB\n", allocatedMemory)
-
-	fmt.Println("\nFinished executing EMHUN algorithm.")
-	outputFileName := "output/BMS_2000000.txt"
-	err = writeResultsToFile(emhun, outputFileName, elapsedTime, allocatedMemory)
-	if err != nil {
-		fmt.Println("Error writing results:", err)
-		return
-	}
+const (
+	exitOK         = 0
+	exitError      = 1
+	exitUsage      = 2
+	exitIncomplete = 3
+)
 
-	fmt.Println("Finished executing EMHUN algorithm. Results written to", outputFileName)
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func readTransactionsFromFile(fileName string) ([]*models.Transaction, error) {
-	file, err := os.Open(fileName)
-	if err != nil {
-		return nil, err
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitUsage
 	}
-	defer file.Close()
-
-	var transactions []*models.Transaction
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, ":")
-		if len(parts) != 3 {
-			fmt.Println("Invalid line format:", line)
-			continue
-		}
-
-		itemsStr := strings.Fields(parts[0])
-		var items []int
-		for _, item := range itemsStr {
-			itemInt, err := strconv.Atoi(item)
-			if err != nil {
-				return nil, err
-			}
-			items = append(items, itemInt)
-		}
-
-		transUtility, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-		if err != nil {
-			return nil, err
-		}
-
-		utilitiesStr := strings.Fields(parts[2])
-		var utilities []float64
-		for _, utility := range utilitiesStr {
-			utilityFloat, err := strconv.ParseFloat(utility, 64)
-			if err != nil {
-				return nil, err
-			}
-			utilities = append(utilities, utilityFloat)
-		}
-
-		// Tạo transaction với các số thực
-		transaction := models.NewTransaction(items, utilities, transUtility)
-		transactions = append(transactions, transaction)
-	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	switch args[0] {
+	case "mine":
+		return runMine(args[1:], stdout, stderr)
+	case "stats":
+		return runStats(args[1:], stdout, stderr)
+	case "validate":
+		return runValidate(args[1:], stdout, stderr)
+	case "convert":
+		return runConvert(args[1:], stdout, stderr)
+	case "recover":
+		return runRecover(args[1:], stdout, stderr)
+	case "generate":
+		return runGenerate(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return exitOK
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return exitUsage
 	}
+}
 
-	return transactions, nil
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: emhun <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	fmt.Fprintln(w, "  mine      mine high utility itemsets from a dataset")
+	fmt.Fprintln(w, "  stats     print statistics about a dataset")
+	fmt.Fprintln(w, "  validate  check a dataset for malformed lines")
+	fmt.Fprintln(w, "  convert   write a dataset as a binary file that loads faster")
+	fmt.Fprintln(w, "  recover   list all HUIs from the result of 'mine --closed'")
+	fmt.Fprintln(w, "  generate  write a synthetic dataset with positive, hybrid and negative items")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'emhun <command> -h' for the flags of a command.")
 }
-func writeResultsToFile(emhun *algorithms.EMHUN, fileName string, elapsedTime float64, allocatedMemory uint64) error {
+
+func writeResultsToFile(writer export.Writer, result *export.Result, fileName string) error {
 	file, err := os.Create(fileName)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	writer := bufio.NewWriter(file)
-
-	// Ghi kết quả thuật toán
-	for _, hui := range emhun.SearchAlgorithms.HighUtilityItemsets {
-		line := fmt.Sprintf("Itemset: %v, Utility: %.2f\n", hui.Itemset, hui.Utility)
-		_, err := writer.WriteString(line)
-		if err != nil {
-			return err
-		}
-	}
-
-	// Ghi thông tin về thời gian (theo giây) và bộ nhớ
-	_, err = writer.WriteString(fmt.Sprintf("\nThời gian chạy thuật toán: %.6f giây\n", elapsedTime))
-	if err != nil {
-		return err
-	}
-
-	_, err = writer.WriteString(fmt.Sprintf("Bộ nhớ sử dụng: %d KB\n", allocatedMemory))
-	if err != nil {
+	if err := writer.Write(file, result); err != nil {
 		return err
 	}
-
-	return writer.Flush()
+	return file.Close()
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"emhun/algorithms"
+	"emhun/dataset"
 	"testing"
 )
 
@@ -10,10 +11,11 @@ func BenchmarkRunEMHUN(b *testing.B) {
 	minUtility := 20000.0
 
 	// Đọc các giao dịch từ file một lần
-	transactions, err := readTransactionsFromFile(fileName)
+	data, err := dataset.ReadFile(fileName, dataset.Lenient)
 	if err != nil {
 		b.Fatal(err)
 	}
+	transactions := data.Transactions
 
 	// Khởi tạo EMHUN với các giao dịch đã đọc
 	emhun := algorithms.NewEMHUN(transactions, minUtility)
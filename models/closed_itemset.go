@@ -0,0 +1,29 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ClosedItemset is the closure of one or more high utility itemsets: the
+// largest itemset contained in every transaction that contains them. All
+// itemsets with the same closure have the same supporting transactions, so
+// the utility of any of them is the sum of its items in ItemUtilities.
+//
+// With negative items the closure itself can be below the threshold;
+// Generators lists the minimal HUIs whose closure this is, which is enough to
+// recover every HUI without the database.
+type ClosedItemset struct {
+	Itemset []int
+	Utility float64
+	// Support is the number of supporting transactions.
+	Support int
+	// ItemUtilities[i] is the utility of Itemset[i] summed over the
+	// supporting transactions.
+	ItemUtilities []float64
+	Generators    [][]int
+}
+
+func (c *ClosedItemset) String() string {
+	return fmt.Sprintf("Itemset: [%s], Utility: %.2f, Support: %d", strings.Trim(fmt.Sprint(c.Itemset), "[]"), c.Utility, c.Support)
+}
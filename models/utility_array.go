@@ -1,45 +1,116 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+)
 
+// UtilityArray stores RTWU, RLU and RSU per item. Items 0..n-1, where n is
+// the size given to NewUtilityArray or Reset, live in slices; EMHUN renames
+// the items to such dense ids before mining. Any other id, negative or
+// beyond n, is kept in a map instead, so a sparse id costs one map entry
+// rather than a slice as long as the id.
 type UtilityArray struct {
-	RTWUs map[int]float64
-	RLUs  map[int]float64
-	RSUs  map[int]float64
+	RTWUs []float64
+	RLUs  []float64
+	RSUs  []float64
+
+	sparse map[int]*[3]float64
+}
+
+const (
+	rtwuIndex = iota
+	rluIndex
+	rsuIndex
+)
+
+func NewUtilityArray(size int) *UtilityArray {
+	ua := &UtilityArray{}
+	ua.Reset(size)
+	return ua
+}
+
+// Reset discards all values and sizes the arrays for items 0..size-1.
+func (ua *UtilityArray) Reset(size int) {
+	ua.RTWUs = make([]float64, size)
+	ua.RLUs = make([]float64, size)
+	ua.RSUs = make([]float64, size)
+	ua.sparse = nil
+}
+
+// Size returns the number of items the arrays hold.
+func (ua *UtilityArray) Size() int {
+	return len(ua.RTWUs)
+}
+
+func (ua *UtilityArray) dense(item int) bool {
+	return item >= 0 && item < len(ua.RTWUs)
+}
+
+func (ua *UtilityArray) setSparse(item, index int, value float64) {
+	if ua.sparse == nil {
+		ua.sparse = make(map[int]*[3]float64)
+	}
+	values, ok := ua.sparse[item]
+	if !ok {
+		values = new([3]float64)
+		ua.sparse[item] = values
+	}
+	values[index] = value
 }
 
-func NewUtilityArray() *UtilityArray {
-	return &UtilityArray{
-		RTWUs: make(map[int]float64),
-		RLUs:  make(map[int]float64),
-		RSUs:  make(map[int]float64),
+func (ua *UtilityArray) getSparse(item, index int) float64 {
+	if values, ok := ua.sparse[item]; ok {
+		return values[index]
 	}
+	return 0
 }
 
 // Setters and Getters for RTWU
 func (ua *UtilityArray) SetRTWU(item int, value float64) {
+	if !ua.dense(item) {
+		ua.setSparse(item, rtwuIndex, value)
+		return
+	}
 	ua.RTWUs[item] = value
 }
 
 func (ua *UtilityArray) GetRTWU(item int) float64 {
+	if !ua.dense(item) {
+		return ua.getSparse(item, rtwuIndex)
+	}
 	return ua.RTWUs[item]
 }
 
 // Setters and Getters for RLU
 func (ua *UtilityArray) SetRLU(item int, value float64) {
+	if !ua.dense(item) {
+		ua.setSparse(item, rluIndex, value)
+		return
+	}
 	ua.RLUs[item] = value
 }
 
 func (ua *UtilityArray) GetRLU(item int) float64 {
+	if !ua.dense(item) {
+		return ua.getSparse(item, rluIndex)
+	}
 	return ua.RLUs[item]
 }
 
 // Setters and Getters for RSU
 func (ua *UtilityArray) SetRSU(item int, value float64) {
+	if !ua.dense(item) {
+		ua.setSparse(item, rsuIndex, value)
+		return
+	}
 	ua.RSUs[item] = value
 }
 
 func (ua *UtilityArray) GetRSU(item int) float64 {
+	if !ua.dense(item) {
+		return ua.getSparse(item, rsuIndex)
+	}
 	return ua.RSUs[item]
 }
 
@@ -49,14 +120,28 @@ func (ua *UtilityArray) PrintUtilityArray() {
 	for item, value := range ua.RTWUs {
 		fmt.Printf("Item %d: %.2f\n", item, value)
 	}
+	ua.printSparse(rtwuIndex)
 
 	fmt.Println("RLU Array:")
 	for item, value := range ua.RLUs {
 		fmt.Printf("Item %d: %.2f\n", item, value)
 	}
+	ua.printSparse(rluIndex)
 
 	fmt.Println("RSU Array:")
 	for item, value := range ua.RSUs {
 		fmt.Printf("Item %d: %.2f\n", item, value)
 	}
+	ua.printSparse(rsuIndex)
+}
+
+func (ua *UtilityArray) printSparse(index int) {
+	items := make([]int, 0, len(ua.sparse))
+	for item := range ua.sparse {
+		items = append(items, item)
+	}
+	sort.Ints(items)
+	for _, item := range items {
+		fmt.Printf("Item %d: %.2f\n", item, ua.sparse[item][index])
+	}
 }
@@ -0,0 +1,42 @@
+package models
+
+// UtilityListEntry holds, for one transaction containing an itemset X, the
+// utility of X and the remaining positive utility after the last item of X.
+type UtilityListEntry struct {
+	Tid              int
+	Utility          float64
+	RemainingUtility float64
+}
+
+// UtilityList is the list of entries of an itemset, ordered by Tid.
+type UtilityList struct {
+	Entries []UtilityListEntry
+}
+
+func NewUtilityList() *UtilityList {
+	return &UtilityList{}
+}
+
+func (ul *UtilityList) Add(tid int, utility, remainingUtility float64) {
+	ul.Entries = append(ul.Entries, UtilityListEntry{Tid: tid, Utility: utility, RemainingUtility: remainingUtility})
+}
+
+// SumUtility returns the utility of the itemset in the database.
+func (ul *UtilityList) SumUtility() float64 {
+	total := 0.0
+	for _, entry := range ul.Entries {
+		total += entry.Utility
+	}
+	return total
+}
+
+// SumPositiveUtility sums max(0, utility) over the entries.
+func (ul *UtilityList) SumPositiveUtility() float64 {
+	total := 0.0
+	for _, entry := range ul.Entries {
+		if entry.Utility > 0 {
+			total += entry.Utility
+		}
+	}
+	return total
+}
@@ -0,0 +1,52 @@
+package utility
+
+import (
+	"emhun/models"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Exact mode (see dataset/fixed.go) keeps utilities scaled to integers in the
+// usual float64 fields; mining itself has no int64 arithmetic. float64 holds
+// every integer up to 2^53 exactly, so when the absolute utilities of the
+// transactions add up to at most MaxExactTotal, every sum computed while
+// mining (RTWU, RSU, RLU, itemset utilities) and every comparison with the
+// threshold is exact. This is a float64 cap: a dataset above it is refused
+// even though its scaled values would still fit in an int64.
+
+// MaxExactTotal is the largest sum of absolute scaled utilities accepted in
+// exact mode.
+const MaxExactTotal = 1 << 53
+
+var (
+	// ErrNotInteger is returned by CheckExact for a utility that is not an
+	// integer.
+	ErrNotInteger = errors.New("utility is not an integer")
+	// ErrExactTotal is returned by CheckExact when the absolute utilities add
+	// up to more than MaxExactTotal.
+	ErrExactTotal = errors.New("total utility exceeds the exact float64 range")
+)
+
+// CheckExact reports an error wrapping ErrNotInteger if a utility of
+// transactions is not an integer, or ErrExactTotal if their absolute values
+// add up to more than MaxExactTotal. Transactions that pass can be mined in
+// exact mode.
+func CheckExact(transactions []*models.Transaction) error {
+	var total uint64
+	for i, transaction := range transactions {
+		for _, u := range transaction.Utilities {
+			if u != math.Trunc(u) {
+				return fmt.Errorf("transaction %d: %w: %g", i+1, ErrNotInteger, u)
+			}
+			if math.Abs(u) > MaxExactTotal {
+				return fmt.Errorf("transaction %d: %w: utility %g", i+1, ErrExactTotal, u)
+			}
+			total += uint64(math.Abs(u))
+			if total > MaxExactTotal {
+				return fmt.Errorf("transaction %d: %w", i+1, ErrExactTotal)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package utility
+
+import (
+	"emhun/models"
+	"errors"
+	"testing"
+)
+
+func TestCheckExact(t *testing.T) {
+	const half = MaxExactTotal / 2
+	tests := []struct {
+		name      string
+		utilities [][]float64
+		err       error
+	}{
+		{"at the limit", [][]float64{{half}, {-half}}, nil},
+		{"one item at the limit", [][]float64{{MaxExactTotal}}, nil},
+		{"one unit over", [][]float64{{half, 1}, {-half}}, ErrExactTotal},
+		{"one item over", [][]float64{{-2 * MaxExactTotal}}, ErrExactTotal},
+		{"fraction", [][]float64{{1, 2.5}}, ErrNotInteger},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var transactions []*models.Transaction
+			for _, utilities := range tt.utilities {
+				items := make([]int, len(utilities))
+				for i := range items {
+					items[i] = i + 1
+				}
+				transactions = append(transactions, models.NewTransaction(items, utilities, 0))
+			}
+			if err := CheckExact(transactions); !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+				t.Errorf("error %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
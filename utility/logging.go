@@ -0,0 +1,37 @@
+package utility
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler drops every record. It reports every level as disabled, so
+// callers that check Enabled skip building the attributes too.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// DiscardLogger returns a logger that drops every record. It is the default
+// of algorithms, so the package is silent when used as a library. The
+// helpers of this package that trace have a WithLogger variant taking the
+// logger as a parameter.
+func DiscardLogger() *slog.Logger {
+	return slog.New(discardHandler{})
+}
+
+// debugEnabled reports whether per-item traces are logged to logger; a nil
+// logger logs nothing.
+func debugEnabled(logger *slog.Logger) bool {
+	return logger != nil && logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// orDiscard returns logger, or a logger that drops everything if it is nil.
+func orDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return DiscardLogger()
+	}
+	return logger
+}
@@ -2,7 +2,8 @@ package utility
 
 import (
 	"emhun/models"
-	"fmt"
+	"log/slog"
+	"math"
 )
 
 func CalculateTransactionUtility(transaction *models.Transaction) float64 {
@@ -13,38 +14,69 @@ func CalculateTransactionUtility(transaction *models.Transaction) float64 {
 	return totalUtility
 }
 
+// CalculateAndPrintAllTransactionUtilities is
+// CalculateAndPrintAllTransactionUtilitiesWithLogger with no logger, so it
+// logs nothing.
 func CalculateAndPrintAllTransactionUtilities(transactions []*models.Transaction) {
+	CalculateAndPrintAllTransactionUtilitiesWithLogger(transactions, nil)
+}
+
+// CalculateAndPrintAllTransactionUtilitiesWithLogger logs the utility of
+// every transaction to logger at info level. A nil logger logs nothing.
+func CalculateAndPrintAllTransactionUtilitiesWithLogger(transactions []*models.Transaction, logger *slog.Logger) {
+	logger = orDiscard(logger)
 	for i, transaction := range transactions {
 		tu := CalculateTransactionUtility(transaction)
-		fmt.Printf("Transaction %d TU: %.2f\n", i+1, tu)
+		logger.Info("transaction utility", "transaction", i+1, "tu", tu)
 	}
 }
 
+// CalculateRLUForAllItemsRhoAnDenta is CalculateRLUForAllItemsRhoAnDentaWithLogger
+// without tracing.
 func CalculateRLUForAllItemsRhoAnDenta(transactions []*models.Transaction, rho, delta map[int]bool, utilityArray *models.UtilityArray) {
+	CalculateRLUForAllItemsRhoAnDentaWithLogger(transactions, rho, delta, utilityArray, nil)
+}
+
+// CalculateRLUForAllItemsRhoAnDentaWithLogger stores the RLU of every item in
+// rho ∪ delta and traces the computation to logger at debug level. A nil
+// logger logs nothing.
+func CalculateRLUForAllItemsRhoAnDentaWithLogger(transactions []*models.Transaction, rho, delta map[int]bool, utilityArray *models.UtilityArray, logger *slog.Logger) {
 	combinedSet := UnionMaps(rho, delta)
+	logger = orDiscard(logger)
+	debug := debugEnabled(logger)
 
 	for item := range combinedSet {
 		totalRLU := 0.0
-		fmt.Printf("\nCalculating RLU for item: %d\n", item)
 
 		for _, transaction := range transactions {
 			if ContainsItem(transaction, item) {
-				fmt.Printf("  Found item %d in transaction: %v\n", item, transaction.Items)
 				rlu := CalculateRemainingResidualUtility(transaction, item)
 				totalRLU += rlu
-				fmt.Printf("  RLU for this transaction: %.2f (cumulative RLU: %.2f)\n", rlu, totalRLU)
+				if debug {
+					logger.Debug("RLU in transaction", "item", item, "items", transaction.Items, "rlu", rlu, "cumulative", totalRLU)
+				}
 			}
 		}
 
 		utilityArray.SetRLU(item, totalRLU)
-		fmt.Printf("Calculated total RLU for item %d: %.2f\n", item, totalRLU)
+		logger.Debug("total RLU", "item", item, "rlu", totalRLU)
 	}
 }
 
+// CalculateRLUForAllItems is CalculateRLUForAllItemsWithLogger without
+// tracing.
 func CalculateRLUForAllItems(transactions []*models.Transaction, secondary []int, utilityArray *models.UtilityArray) {
+	CalculateRLUForAllItemsWithLogger(transactions, secondary, utilityArray, nil)
+}
+
+// CalculateRLUForAllItemsWithLogger stores the RLU of every item in secondary
+// and traces the computation to logger at debug level. A nil logger logs
+// nothing.
+func CalculateRLUForAllItemsWithLogger(transactions []*models.Transaction, secondary []int, utilityArray *models.UtilityArray, logger *slog.Logger) {
+	logger = orDiscard(logger)
+	debug := debugEnabled(logger)
 	for _, item := range secondary {
 		totalRLU := 0.0
-		fmt.Printf("\nCalculating RLU for item: %d\n", item)
 
 		for _, transaction := range transactions {
 			if ContainsItem(transaction, item) {
@@ -53,38 +85,36 @@ func CalculateRLUForAllItems(transactions []*models.Transaction, secondary []int
 				remainingUtility := CalculateRemainingUtility(transaction, index+1)
 				totalRLU += itemUtility + remainingUtility
 
-				fmt.Printf("  Found item %d in transaction %v with utility: %.2f, Remaining Residual Utility: %.2f\n",
-					item, transaction.Items, itemUtility, remainingUtility)
+				if debug {
+					logger.Debug("RLU in transaction", "item", item, "items", transaction.Items,
+						"utility", itemUtility, "remaining", remainingUtility)
+				}
 			}
 		}
 
 		utilityArray.SetRLU(item, totalRLU)
-		fmt.Printf("Calculated total RLU for item %d: %.2f\n", item, totalRLU)
+		logger.Debug("total RLU", "item", item, "rlu", totalRLU)
 	}
 }
 
 func CalculateRemainingResidualUtility(transaction *models.Transaction, currentItem int) float64 {
 	foundCurrentItem := false
 	rru := 0.0
-	fmt.Printf("    Remaining items after %d: ", currentItem)
 
 	for i, item := range transaction.Items {
 		utility := transaction.Utilities[i]
 
 		if foundCurrentItem && utility > 0 {
 			rru += utility
-			fmt.Printf("%d(%.2f) ", item, utility)
 		}
 
 		if item == currentItem {
 			foundCurrentItem = true
 			if utility > 0 {
 				rru += utility
-				fmt.Printf("    Adding utility of currentItem %d: %.2f\n", currentItem, utility)
 			}
 		}
 	}
-	fmt.Println()
 	return rru
 }
 
@@ -115,6 +145,16 @@ func CalculateRTUForTransaction(transaction *models.Transaction) float64 {
 	return rtwu
 }
 
+// CalculateTotalRTU sums the RTU of every transaction, i.e. the total positive
+// utility of the database.
+func CalculateTotalRTU(transactions []*models.Transaction) float64 {
+	total := 0.0
+	for _, transaction := range transactions {
+		total += CalculateRTUForTransaction(transaction)
+	}
+	return total
+}
+
 func CalculateRSUForAllItems(transactions []*models.Transaction, secondary []int, utilityArray *models.UtilityArray) {
 	for _, item := range secondary {
 		totalRSU := 0.0
@@ -124,7 +164,7 @@ func CalculateRSUForAllItems(transactions []*models.Transaction, secondary []int
 				index := GetItemIndex(transaction, item)
 				itemUtility := transaction.Utilities[index]
 				remainingUtility := CalculateRemainingUtility(transaction, index+1)
-				totalRSU += itemUtility + remainingUtility
+				totalRSU += math.Max(0, itemUtility+remainingUtility)
 			}
 		}
 
@@ -142,6 +182,10 @@ func CalculateRemainingUtility(transaction *models.Transaction, startIndex int)
 	return remainingUtility
 }
 
+// CalculateRSUForAllItem computes RSU(X, z) for every z in secondary. Each
+// transaction contributes max(0, u(X)+u(z)+rru(z)): with hybrid items in X the
+// sum can be negative in some transactions, and an extension that drops those
+// transactions would otherwise exceed the bound.
 func CalculateRSUForAllItem(transactions []*models.Transaction, X []int, secondary []int, utilityArray *models.UtilityArray) {
 	for _, item := range secondary {
 		totalRSU := 0.0
@@ -152,7 +196,7 @@ func CalculateRSUForAllItem(transactions []*models.Transaction, X []int, seconda
 				indexZ := GetItemIndex(transaction, item)
 				utilityZ := transaction.Utilities[indexZ]
 				rru := CalculateRemainingUtility(transaction, indexZ+1)
-				totalRSU += utilityX + utilityZ + rru
+				totalRSU += math.Max(0, utilityX+utilityZ+rru)
 			}
 		}
 
@@ -160,6 +204,9 @@ func CalculateRSUForAllItem(transactions []*models.Transaction, X []int, seconda
 	}
 }
 
+// CalculateRLUForAllItem computes RLU(X, z) for every z in secondary, with
+// rru(X) taken after the last item of X and each transaction clamped at zero
+// for the same reason as RSU.
 func CalculateRLUForAllItem(transactions []*models.Transaction, X []int, secondary []int, utilityArray *models.UtilityArray) {
 	for _, item := range secondary {
 		totalRLU := 0.0
@@ -168,11 +215,9 @@ func CalculateRLUForAllItem(transactions []*models.Transaction, X []int, seconda
 			if ContainsAllItems(transaction, X) && ContainsItem(transaction, item) {
 				utilityX := CalculateUtilityForSet(transaction, X)
 				maxIndexX := FindLocationMaxIndexForSet(transaction, X)
-				index := GetItemIndex(transaction, maxIndexX)
+				remainingUtility := CalculateRemainingUtility(transaction, maxIndexX+1)
 
-				remainingUtility := CalculateRemainingUtility(transaction, index+2)
-
-				totalRLU += utilityX + remainingUtility
+				totalRLU += math.Max(0, utilityX+remainingUtility)
 			}
 		}
 
@@ -191,6 +236,19 @@ func CalculateUtilityForSet(transaction *models.Transaction, X []int) float64 {
 	return totalUtility
 }
 
+// CalculatePositiveUtilityForSet sums max(0, u(X,T)) over the transactions
+// containing X. Extending X with η items can only lower u(X,T) and drop
+// transactions, so this bounds every such extension.
+func CalculatePositiveUtilityForSet(transactions []*models.Transaction, X []int) float64 {
+	total := 0.0
+	for _, transaction := range transactions {
+		if ContainsAllItems(transaction, X) {
+			total += math.Max(0, CalculateUtilityForSet(transaction, X))
+		}
+	}
+	return total
+}
+
 func FindLocationMaxIndexForSet(transaction *models.Transaction, X []int) int {
 	maxIndex := -1
 	for _, item := range X {
@@ -0,0 +1,327 @@
+package utility
+
+import (
+	"bytes"
+	"emhun/models"
+	"log/slog"
+	"maps"
+	"math/bits"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestBoundRegressions covers databases where RSU and RLU used to be below
+// the utility of an extension they prune.
+func TestBoundRegressions(t *testing.T) {
+	tests := []struct {
+		name         string
+		transactions []*models.Transaction
+		X            []int
+		z            int
+		rsu, rlu     float64
+	}{
+		{
+			// u(X)+u(z)+rru(z) < 0 trong T1, mà {1,2,3} chỉ có trong T2
+			name: "negative transaction",
+			transactions: []*models.Transaction{
+				models.NewTransaction([]int{1, 2}, []float64{-10, 1}, -9),
+				models.NewTransaction([]int{1, 2, 3}, []float64{5, 1, 1}, 7),
+			},
+			X: []int{1}, z: 2, rsu: 7, rlu: 7,
+		},
+		{
+			// rru(X) bắt đầu ngay sau item cuối của X, không phải hai vị trí sau
+			name: "remaining utility after X",
+			transactions: []*models.Transaction{
+				models.NewTransaction([]int{0, 1, 2}, []float64{1, 1, 5}, 7),
+			},
+			X: []int{0}, z: 1, rsu: 7, rlu: 7,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ua := models.NewUtilityArray(4)
+			CalculateRSUForAllItem(tt.transactions, tt.X, []int{tt.z}, ua)
+			CalculateRLUForAllItem(tt.transactions, tt.X, []int{tt.z}, ua)
+			if got := ua.GetRSU(tt.z); got != tt.rsu {
+				t.Errorf("RSU(%v, %d) = %g, want %g", tt.X, tt.z, got, tt.rsu)
+			}
+			if got := ua.GetRLU(tt.z); got != tt.rlu {
+				t.Errorf("RLU(%v, %d) = %g, want %g", tt.X, tt.z, got, tt.rlu)
+			}
+		})
+	}
+}
+
+// TestSparseItemIDs checks the helpers on dataset ids that are not dense:
+// negative and far beyond the number of items.
+func TestSparseItemIDs(t *testing.T) {
+	const big = 3000000000
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{-3, 1, big}, []float64{5, 2, -1}, 6),
+		models.NewTransaction([]int{-3, big}, []float64{4, -2}, 2),
+	}
+	rho := map[int]bool{-3: true, 1: true}
+	eta := map[int]bool{big: true}
+	ua := models.NewUtilityArray(0)
+	CalculateRTWUForAllItems(transactions, rho, map[int]bool{}, eta, ua)
+	CalculateRSUForAllItems(transactions, []int{-3, 1}, ua)
+	for _, tt := range []struct {
+		item      int
+		rtwu, rsu float64
+	}{
+		{-3, 11, 11},
+		{1, 7, 2},
+		{big, 11, 0},
+	} {
+		if got := ua.GetRTWU(tt.item); got != tt.rtwu {
+			t.Errorf("RTWU(%d) = %g, want %g", tt.item, got, tt.rtwu)
+		}
+		if got := ua.GetRSU(tt.item); got != tt.rsu {
+			t.Errorf("RSU(%d) = %g, want %g", tt.item, got, tt.rsu)
+		}
+	}
+	if ua.Size() != 0 {
+		t.Errorf("arrays grew to %d items", ua.Size())
+	}
+}
+
+// TestHelpersLogToGivenLogger checks that the traces go to the logger passed
+// in, and that a nil logger is silent.
+func TestHelpersLogToGivenLogger(t *testing.T) {
+	transactions := []*models.Transaction{
+		models.NewTransaction([]int{1, 2}, []float64{3, 4}, 7),
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	CalculateRLUForAllItemsWithLogger(transactions, []int{1}, models.NewUtilityArray(3), logger)
+	if !strings.Contains(buf.String(), "total RLU") {
+		t.Errorf("nothing logged, got %q", buf.String())
+	}
+	CalculateRLUForAllItemsWithLogger(transactions, []int{1}, models.NewUtilityArray(3), nil)
+	CalculateRLUForAllItems(transactions, []int{1}, models.NewUtilityArray(3))
+}
+
+// maxTestItems keeps the exhaustive checks below small: every itemset over
+// items 1..maxTestItems is enumerated as a bitmask.
+const maxTestItems = 7
+
+// transactionsFromBytes decodes fuzz input into a database over items
+// 1..maxTestItems. Items are sorted, so the processing order is the item id.
+func transactionsFromBytes(data []byte) []*models.Transaction {
+	var transactions []*models.Transaction
+	for len(data) > 0 && len(transactions) < 10 {
+		length := int(data[0]%maxTestItems) + 1
+		data = data[1:]
+		utilities := make(map[int]float64)
+		for ; length > 0 && len(data) >= 2; length-- {
+			if u := float64(int8(data[1])); u != 0 {
+				utilities[int(data[0]%maxTestItems)+1] = u
+			}
+			data = data[2:]
+		}
+		if len(utilities) == 0 {
+			continue
+		}
+		transaction := models.NewTransaction(nil, nil, 0)
+		for _, item := range slices.Sorted(maps.Keys(utilities)) {
+			transaction.Items = append(transaction.Items, item)
+			transaction.Utilities = append(transaction.Utilities, utilities[item])
+		}
+		transaction.TransactionUtility = CalculateTransactionUtility(transaction)
+		transactions = append(transactions, transaction)
+	}
+	return transactions
+}
+
+// randomBytes returns fuzz-style input where every item has a fixed sign
+// or, for hybrid items, a random one.
+func randomBytes(r *rand.Rand) []byte {
+	kinds := make([]int, maxTestItems)
+	for i := range kinds {
+		kinds[i] = r.Intn(3)
+	}
+	var data []byte
+	for range 2 + r.Intn(8) {
+		length := 1 + r.Intn(maxTestItems)
+		data = append(data, byte(length-1))
+		for range length {
+			item := r.Intn(maxTestItems)
+			u := 1 + r.Intn(20)
+			if kinds[item] == 2 || kinds[item] == 1 && r.Intn(2) == 0 {
+				u = -u
+			}
+			data = append(data, byte(item), byte(int8(u)))
+		}
+	}
+	return data
+}
+
+// itemsOf returns the items of a bitmask, in processing order.
+func itemsOf(mask uint) []int {
+	var items []int
+	for mask != 0 {
+		i := bits.TrailingZeros(mask)
+		items = append(items, i+1)
+		mask &^= 1 << i
+	}
+	return items
+}
+
+// realUtility is u(X): the utility of X summed over the transactions
+// containing it.
+func realUtility(transactions []*models.Transaction, X []int) float64 {
+	total := 0.0
+	for _, transaction := range transactions {
+		if ContainsAllItems(transaction, X) {
+			total += CalculateUtilityForSet(transaction, X)
+		}
+	}
+	return total
+}
+
+// checkBounds asserts that every bound is at least the utility of each
+// itemset pruned when the bound is below the threshold.
+func checkBounds(t *testing.T, transactions []*models.Transaction) {
+	t.Helper()
+	const all = 1<<maxTestItems - 1
+	utilities := make([]float64, all+1)
+	for mask := uint(1); mask <= all; mask++ {
+		utilities[mask] = realUtility(transactions, itemsOf(mask))
+	}
+	// above returns the items after item in processing order.
+	above := func(item int) uint { return all &^ (1<<item - 1) }
+
+	failed := 0
+	fail := func(format string, args ...any) {
+		t.Helper()
+		if failed++; failed <= 10 {
+			t.Errorf(format, args...)
+		}
+	}
+
+	every := make([]int, maxTestItems)
+	for i := range every {
+		every[i] = i + 1
+	}
+	rho, delta, eta := map[int]bool{}, map[int]bool{}, map[int]bool{}
+	for _, item := range every {
+		positive, negative := false, false
+		for _, transaction := range transactions {
+			if i := GetItemIndex(transaction, item); i >= 0 {
+				positive = positive || transaction.Utilities[i] > 0
+				negative = negative || transaction.Utilities[i] < 0
+			}
+		}
+		switch {
+		case positive && negative:
+			delta[item] = true
+		case negative:
+			eta[item] = true
+		case positive:
+			rho[item] = true
+		}
+	}
+
+	// RTWU(i) >= u(X) cho mọi X chứa i
+	ua := models.NewUtilityArray(maxTestItems + 1)
+	CalculateRTWUForAllItems(transactions, rho, delta, eta, ua)
+	for mask := uint(1); mask <= all; mask++ {
+		for _, item := range itemsOf(mask) {
+			if rtwu := ua.GetRTWU(item); rtwu < utilities[mask] {
+				fail("RTWU(%d) = %g < u(%v) = %g", item, rtwu, itemsOf(mask), utilities[mask])
+			}
+		}
+	}
+
+	// RSU(z) >= u({z} ∪ W) với W gồm các item sau z
+	ua = models.NewUtilityArray(maxTestItems + 1)
+	CalculateRSUForAllItems(transactions, every, ua)
+	for _, z := range every {
+		rest := above(z)
+		for w := rest; ; w = (w - 1) & rest {
+			if X := w | 1<<(z-1); ua.GetRSU(z) < utilities[X] {
+				fail("RSU(%d) = %g < u(%v) = %g", z, ua.GetRSU(z), itemsOf(X), utilities[X])
+			}
+			if w == 0 {
+				break
+			}
+		}
+	}
+
+	for prefix := uint(1); prefix <= all; prefix++ {
+		X := itemsOf(prefix)
+		last := X[len(X)-1]
+		secondary := itemsOf(above(last))
+		if len(secondary) == 0 {
+			continue
+		}
+		ua = models.NewUtilityArray(maxTestItems + 1)
+		CalculateRSUForAllItem(transactions, X, secondary, ua)
+		CalculateRLUForAllItem(transactions, X, secondary, ua)
+
+		for _, z := range secondary {
+			// RSU(X, z) >= u(X ∪ {z} ∪ W) với W gồm các item sau z
+			rest := above(z)
+			for w := rest; ; w = (w - 1) & rest {
+				if Y := prefix | 1<<(z-1) | w; ua.GetRSU(z) < utilities[Y] {
+					fail("RSU(%v, %d) = %g < u(%v) = %g", X, z, ua.GetRSU(z), itemsOf(Y), utilities[Y])
+				}
+				if w == 0 {
+					break
+				}
+			}
+			// RLU(X, z) >= u(X ∪ {z} ∪ W) với W gồm các item sau X
+			rest = above(last) &^ (1 << (z - 1))
+			for w := rest; ; w = (w - 1) & rest {
+				if Y := prefix | 1<<(z-1) | w; ua.GetRLU(z) < utilities[Y] {
+					fail("RLU(%v, %d) = %g < u(%v) = %g", X, z, ua.GetRLU(z), itemsOf(Y), utilities[Y])
+				}
+				if w == 0 {
+					break
+				}
+			}
+		}
+
+		// Thêm item η chỉ làm giảm utility
+		bound := CalculatePositiveUtilityForSet(transactions, X)
+		var negative uint
+		for item := range eta {
+			negative |= 1 << (item - 1)
+		}
+		negative &^= prefix
+		for e := negative; e != 0; e = (e - 1) & negative {
+			if Y := prefix | e; bound < utilities[Y] {
+				fail("positive utility of %v = %g < u(%v) = %g", X, bound, itemsOf(Y), utilities[Y])
+			}
+		}
+	}
+
+	if failed > 0 {
+		for _, transaction := range transactions {
+			t.Log(transaction)
+		}
+	}
+}
+
+func TestBoundsRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for range 300 {
+		checkBounds(t, transactionsFromBytes(randomBytes(r)))
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+func FuzzBounds(f *testing.F) {
+	r := rand.New(rand.NewSource(2))
+	for range 20 {
+		f.Add(randomBytes(r))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		checkBounds(t, transactionsFromBytes(data))
+	})
+}